@@ -0,0 +1,225 @@
+package today
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// An Event is a calendar event read from an iCalendar file by ParseICalEvents.
+type Event struct {
+	UID      string
+	Summary  string
+	Location string
+	Start    time.Time
+	End      time.Time
+	// AllDay is true for events that take up whole days rather than starting at a time.
+	AllDay bool
+	// Recurrence is the event's RRULE, if it repeats, and Except holds the starts of repetitions
+	// that were cancelled (EXDATE).
+	Recurrence string
+	Except     []time.Time
+}
+
+// icalUnescape undoes icalEscape.
+var icalUnescape = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+// icalProperty splits an unfolded content line into its name, parameters and value.
+func icalProperty(line string) (name string, params map[string]string, value string) {
+	quoted := false
+	colon := -1
+	for i, c := range line {
+		if c == '"' {
+			quoted = !quoted
+		} else if c == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return "", nil, ""
+	}
+	parts := strings.Split(line[:colon], ";")
+	params = make(map[string]string)
+	for _, p := range parts[1:] {
+		if i := strings.Index(p, "="); i > 0 {
+			params[strings.ToUpper(p[:i])] = strings.Trim(p[i+1:], `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, line[colon+1:]
+}
+
+// icalTime parses a DATE or DATE-TIME value. Times in UTC end in "Z", times with a TZID are in
+// that zone if it is known, and any other times are local.
+func icalTime(params map[string]string, value string) (t time.Time, allDay bool, ok bool) {
+	if params["VALUE"] == "DATE" || len(value) == len(icalDate) {
+		t, err := time.ParseInLocation(icalDate, value, time.Local)
+		return t, true, err == nil
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(icalDateTime, value)
+		return t.Local(), false, err == nil
+	}
+	loc := time.Local
+	if tzid, found := params["TZID"]; found {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t.Local(), false, err == nil
+}
+
+// ParseICalEvents reads the VEVENTs out of an iCalendar file. Only the properties in Event are
+// read, and everything else, such as VTODOs and VTIMEZONE definitions, is skipped. Time zones are
+// looked up by name in the system's time zone database.
+func ParseICalEvents(r io.Reader) ([]Event, error) {
+	var (
+		events []Event
+		lines  []string
+	)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var e *Event
+	for _, line := range lines {
+		name, params, value := icalProperty(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			e = &Event{}
+		case name == "END" && value == "VEVENT" && e != nil:
+			if !e.Start.IsZero() {
+				events = append(events, *e)
+			}
+			e = nil
+		case e == nil:
+		case name == "UID":
+			e.UID = value
+		case name == "SUMMARY":
+			e.Summary = icalUnescape.Replace(value)
+		case name == "LOCATION":
+			e.Location = icalUnescape.Replace(value)
+		case name == "DTSTART":
+			e.Start, e.AllDay, _ = icalTime(params, value)
+		case name == "DTEND":
+			e.End, _, _ = icalTime(params, value)
+		case name == "RRULE":
+			e.Recurrence = value
+		case name == "EXDATE":
+			for _, v := range strings.Split(value, ",") {
+				if t, _, ok := icalTime(params, v); ok {
+					e.Except = append(e.Except, t)
+				}
+			}
+		}
+	}
+	return events, nil
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// On reports whether e happens on day, and if it does, when it starts that day. Repeating events
+// are supported for the common cases of RRULE: daily, weekly (with BYDAY), monthly on the same day
+// of the month, and yearly, with INTERVAL, UNTIL and COUNT. Cancelled repetitions are skipped.
+func (e Event) On(day time.Time) (time.Time, bool) {
+	start := e.Start
+	first := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.Local)
+	target := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local)
+	at := func(d time.Time) time.Time {
+		return time.Date(d.Year(), d.Month(), d.Day(), start.Hour(), start.Minute(), start.Second(), 0, time.Local)
+	}
+	if target.Before(first) {
+		return time.Time{}, false
+	}
+	if e.Recurrence == "" {
+		return start, sameDay(start, target)
+	}
+
+	rule := make(map[string]string)
+	for _, part := range strings.Split(e.Recurrence, ";") {
+		if i := strings.Index(part, "="); i > 0 {
+			rule[strings.ToUpper(part[:i])] = part[i+1:]
+		}
+	}
+	interval, err := strconv.Atoi(rule["INTERVAL"])
+	if err != nil || interval < 1 {
+		interval = 1
+	}
+	count, _ := strconv.Atoi(rule["COUNT"])
+	var until time.Time
+	if v, ok := rule["UNTIL"]; ok {
+		var allDay bool
+		until, allDay, _ = icalTime(nil, v)
+		if allDay {
+			until = until.AddDate(0, 0, 1).Add(-time.Second)
+		}
+	}
+	byDay := make(map[time.Weekday]bool)
+	for _, d := range strings.Split(rule["BYDAY"], ",") {
+		for i, name := range []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"} {
+			if d == name {
+				byDay[time.Weekday(i)] = true
+			}
+		}
+	}
+	if len(byDay) == 0 {
+		byDay[first.Weekday()] = true
+	}
+
+	matches := func(d time.Time) bool {
+		days := int(d.Sub(first).Hours()/24 + 0.5)
+		switch rule["FREQ"] {
+		case "DAILY":
+			return days%interval == 0
+		case "WEEKLY":
+			weekStart := first.AddDate(0, 0, -int(first.Weekday()))
+			weeks := int(d.Sub(weekStart).Hours()/24+0.5) / 7
+			return byDay[d.Weekday()] && weeks%interval == 0
+		case "MONTHLY":
+			months := (d.Year()-first.Year())*12 + int(d.Month()-first.Month())
+			return d.Day() == first.Day() && months%interval == 0
+		case "YEARLY":
+			return d.Month() == first.Month() && d.Day() == first.Day() && (d.Year()-first.Year())%interval == 0
+		}
+		return d.Equal(first)
+	}
+
+	n := 0
+	for d := first; !d.After(target); d = d.AddDate(0, 0, 1) {
+		if !matches(d) {
+			continue
+		}
+		if !until.IsZero() && at(d).After(until) {
+			return time.Time{}, false
+		}
+		n++
+		if count > 0 && n > count {
+			return time.Time{}, false
+		}
+		if d.Equal(target) {
+			for _, x := range e.Except {
+				if sameDay(x, d) {
+					return time.Time{}, false
+				}
+			}
+			return at(d), true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,104 @@
+package today
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testCalendar = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup@example.com\r\n" +
+	"DTSTART:20200106T093000\r\n" +
+	"DTEND:20200106T094500\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR;UNTIL=20200131\r\n" +
+	"EXDATE:20200110T093000\r\n" +
+	"SUMMARY:Standup\\, daily\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:review@example.com\r\n" +
+	"DTSTART:20200108T140000\r\n" +
+	"SUMMARY:Design review for the new frobnicator with the whole team and then s\r\n" +
+	" ome\r\n" +
+	"LOCATION:Room 4\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:holiday@example.com\r\n" +
+	"DTSTART;VALUE=DATE:20200108\r\n" +
+	"SUMMARY:Holiday\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VTODO\r\n" +
+	"SUMMARY:Not an event\r\n" +
+	"END:VTODO\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseICalEvents(t *testing.T) {
+	events, err := ParseICalEvents(strings.NewReader(testCalendar))
+	assert.NoError(t, err)
+	if !assert.Len(t, events, 3) {
+		return
+	}
+	assert.Equal(t, "Standup, daily", events[0].Summary)
+	assert.Equal(t, time.Date(2020, 1, 6, 9, 30, 0, 0, time.Local), events[0].Start)
+	assert.Equal(t, "Design review for the new frobnicator with the whole team and then some", events[1].Summary)
+	assert.Equal(t, "Room 4", events[1].Location)
+	assert.True(t, events[2].AllDay)
+	assert.False(t, events[1].AllDay)
+}
+
+func TestEventOn(t *testing.T) {
+	events, _ := ParseICalEvents(strings.NewReader(testCalendar))
+	d := func(day int) time.Time { return time.Date(2020, 1, day, 12, 0, 0, 0, time.Local) }
+
+	standup := events[0]
+	for day, want := range map[int]bool{5: false, 6: true, 7: false, 8: true, 10: false, 13: true, 31: true} {
+		at, ok := standup.On(d(day))
+		assert.Equal(t, want, ok, "Jan %d", day)
+		if ok {
+			assert.Equal(t, time.Date(2020, 1, day, 9, 30, 0, 0, time.Local), at)
+		}
+	}
+	_, ok := standup.On(time.Date(2020, 2, 3, 0, 0, 0, 0, time.Local))
+	assert.False(t, ok)
+
+	_, ok = events[1].On(d(8))
+	assert.True(t, ok)
+	_, ok = events[1].On(d(9))
+	assert.False(t, ok)
+
+	daily := Event{Start: d(1), Recurrence: "FREQ=DAILY;INTERVAL=2;COUNT=3"}
+	for day, want := range map[int]bool{1: true, 2: false, 3: true, 5: true, 7: false} {
+		_, ok := daily.On(d(day))
+		assert.Equal(t, want, ok, "Jan %d", day)
+	}
+}
+
+func TestLogInsert(t *testing.T) {
+	day := time.Date(2020, 1, 6, 0, 0, 0, 0, time.Local)
+	at := func(h, m int) time.Time { return time.Date(2020, 1, 6, h, m, 0, 0, time.Local) }
+	log := Lines{"8:30 - Starting work", "11:00 - Lunch", ""}
+
+	assert.True(t, log.Insert(NewLogEntryAt(at(9, 30), "Standup"), day))
+	assert.False(t, log.Insert(NewLogEntryAt(at(9, 30), "Standup"), day))
+	assert.True(t, log.Insert(NewLogEntryAt(at(14, 0), "Review"), day))
+	assert.Equal(t, Lines{"8:30 - Starting work", "9:30 - Standup", "11:00 - Lunch", "2:00 - Review", ""}, log)
+
+	// Re-inserting a PM event into a Log without AM or PM doesn't add it again, and later PM events
+	// go after it.
+	assert.False(t, log.Insert(NewLogEntryAt(at(14, 0), "Review"), day))
+	assert.True(t, log.Insert(NewLogEntryAt(at(13, 0), "Planning"), day))
+	assert.True(t, log.Insert(NewLogEntryAt(at(16, 30), "Retro"), day))
+	assert.Equal(t, Lines{"8:30 - Starting work", "9:30 - Standup", "11:00 - Lunch", "1:00 - Planning", "2:00 - Review", "4:30 - Retro", ""}, log)
+	assert.False(t, log.Insert(NewLogEntryAt(at(13, 0), "Planning"), day))
+
+	// With nothing before it to go by, an early PM entry is still recognized as a duplicate.
+	pm := Lines{"2:00 - Review"}
+	assert.False(t, pm.Insert(NewLogEntryAt(at(14, 0), "Review"), day))
+
+	var empty Lines
+	assert.True(t, empty.Insert(NewLogEntryAt(at(14, 0), "Review"), day))
+	assert.Equal(t, Lines{"2:00PM - Review"}, empty)
+}
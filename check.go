@@ -0,0 +1,157 @@
+package today
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// A Problem is something wrong with a line of a today file, found by Check.
+type Problem struct {
+	// Line is the number of the line, starting at 1. Start and End are the byte offsets within the
+	// line of the text the Problem is about.
+	Line       int
+	Start, End int
+	Message    string
+	// Warning is set for problems that don't lose or change anything, such as unknown statuses.
+	Warning bool
+}
+
+func (p Problem) String() string {
+	kind := "error"
+	if p.Warning {
+		kind = "warning"
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", p.Line, p.Start+1, kind, p.Message)
+}
+
+// dateLikeRe matches things that look like they were meant to be dates, but might not be in the
+// "Jan _2, 2006" format.
+var dateLikeRe = regexp.MustCompile(`^([A-Za-z]{3,9}\.?[[:space:]]+[0-9]{1,2}(st|nd|rd|th)?,?[[:space:]]*[0-9]{2,4}|[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}|[0-9]{1,2}/[0-9]{1,2}(/[0-9]{2,4})?)$`)
+
+// StatusNames returns the status names that TaskList.Sort knows about, in the order it sorts them.
+func StatusNames() []string {
+	var names []string
+	for name := range priorityOrder {
+		if name != "" && name != "OTHER" {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if priorityOrder[names[i]] != priorityOrder[names[j]] {
+			return priorityOrder[names[i]] < priorityOrder[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// checkStatus checks the status found between start and end of line.
+func checkStatus(line string, n, start, end int) []Problem {
+	text := line[start:end]
+	s := parseStatus(strings.TrimSpace(text))
+	var problems []Problem
+	if _, ok := priorityOrder[s.Name]; !ok {
+		i := strings.Index(text, s.Name)
+		problems = append(problems, Problem{
+			Line:    n,
+			Start:   start + i,
+			End:     start + i + len(s.Name),
+			Message: fmt.Sprintf("unknown status %s, sorted with new tasks", s.Name),
+			Warning: true,
+		})
+	}
+	if s.Date.IsZero() {
+		if i := strings.LastIndex(text, " - "); i >= 0 {
+			date := strings.TrimSpace(text[i+3:])
+			if dateLikeRe.MatchString(date) {
+				j := start + i + 3 + strings.Index(text[i+3:], date)
+				problems = append(problems, Problem{
+					Line:    n,
+					Start:   j,
+					End:     j + len(date),
+					Message: fmt.Sprintf("can't parse date %q, so it is part of the comment; dates look like \"Jan 2, 2006\"", date),
+				})
+			}
+		}
+	}
+	return problems
+}
+
+// Check reads a today file from r and returns the problems in it: unknown statuses, dates that
+// can't be parsed, duplicate task names, and text that Parse would ignore.
+func Check(r io.Reader) ([]Problem, error) {
+	var (
+		problems []Problem
+		section  = -1
+		names    = make(map[string]int)
+		n        int
+	)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		n++
+		line := scanner.Text()
+		if next := nextSection(section, line); next != section {
+			section = next
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		indent := strings.Index(line, trimmed)
+		switch {
+		case section < 0:
+			problems = append(problems, Problem{
+				Line:    n,
+				Start:   indent,
+				End:     indent + len(trimmed),
+				Message: fmt.Sprintf("text before %q is ignored", startupLine),
+			})
+		case sectionHeaders[section] == startupLine:
+			m := listItemRe.FindStringSubmatchIndex(trimmed)
+			if m[10] >= 0 {
+				problems = append(problems, checkStatus(line, n, indent+m[10], indent+m[11])...)
+			}
+		case sectionHeaders[section] == todoLine:
+			if strings.HasPrefix(line, "\t") {
+				// A comment.
+				continue
+			}
+			m := taskRe.FindStringSubmatchIndex(trimmed)
+			if m[4] >= 0 {
+				name := trimmed[m[4]:m[5]]
+				if first, ok := names[name]; ok {
+					problems = append(problems, Problem{
+						Line:    n,
+						Start:   indent + m[4],
+						End:     indent + m[5],
+						Message: fmt.Sprintf("duplicate task name %s, first used on line %d", name, first),
+					})
+				} else {
+					names[name] = n
+				}
+			}
+			if m[10] >= 0 {
+				problems = append(problems, checkStatus(line, n, indent+m[10], indent+m[11])...)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if section+1 < len(sectionHeaders) {
+		if n == 0 {
+			n = 1
+		}
+		problems = append(problems, Problem{
+			Line:    n,
+			Message: fmt.Sprintf("missing %q section", sectionHeaders[section+1]),
+		})
+	}
+	return problems, nil
+}
@@ -0,0 +1,50 @@
+package today
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	problems, err := Check(strings.NewReader(`stray line
+Morning Start Up:
+1. Catch up on slack [DONE - Jan 5, 2020]
+2. Check the calendar [DONE - Jan 45, 2020]
+
+Notes:
+[WHATEVER] notes aren't checked
+Log:
+4:48PM - Moved TASK-123 (Do something important) to DONE
+
+TODO:
+JIRA-12 - Fix the frobnicator [IN PROGRESS - pr #3 - Jan 5, 2020]
+	comment [NOT A STATUS]
+JIRA-13 - Ask about it [BLOCKED - on ops]
+JIRA-12 - Fix it again [READY - 2020-01-05]
+Write docs [DONE - Jan 5 2020]
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, []Problem{
+		{Line: 1, Start: 0, End: 10, Message: `text before "Morning Start Up:" is ignored`},
+		{Line: 4, Start: 30, End: 42, Message: `can't parse date "Jan 45, 2020", so it is part of the comment; dates look like "Jan 2, 2006"`},
+		{Line: 14, Start: 24, End: 31, Message: "unknown status BLOCKED, sorted with new tasks", Warning: true},
+		{Line: 15, Start: 0, End: 7, Message: "duplicate task name JIRA-12, first used on line 12"},
+		{Line: 15, Start: 32, End: 42, Message: `can't parse date "2020-01-05", so it is part of the comment; dates look like "Jan 2, 2006"`},
+		{Line: 16, Start: 19, End: 29, Message: `can't parse date "Jan 5 2020", so it is part of the comment; dates look like "Jan 2, 2006"`},
+	}, problems)
+	assert.Equal(t, "14:25: warning: unknown status BLOCKED, sorted with new tasks", problems[2].String())
+
+	problems, err = Check(strings.NewReader("Morning Start Up:\nNotes:\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, []Problem{{Line: 2, Message: `missing "Log:" section`}}, problems)
+}
+
+func TestStatusNames(t *testing.T) {
+	names := StatusNames()
+	assert.Equal(t, "?", names[0])
+	assert.Equal(t, "DONE", names[len(names)-1])
+	assert.Contains(t, names, "IN PROGRESS")
+	assert.NotContains(t, names, "OTHER")
+}
@@ -0,0 +1,58 @@
+package today
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// csvDate is the format of dates in CSV output, which spreadsheets recognize as dates.
+const csvDate = "2006-01-02"
+
+var csvHeader = []string{"name", "description", "status", "status_comment", "status_date", "comments"}
+
+func csvRow(task *Task) []string {
+	date := ""
+	if !task.Status.Date.IsZero() {
+		date = task.Status.Date.Format(csvDate)
+	}
+	return []string{
+		task.Name,
+		task.Description,
+		task.Status.Name,
+		task.Status.Comment,
+		date,
+		strconv.Itoa(len(task.Comments)),
+	}
+}
+
+// WriteCSV writes the tasks of t to w as CSV, with a header row and then a row for each task
+// holding its name, description, status name, status comment, status date and number of comments.
+func (t *TaskList) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write(csvHeader)
+	for _, task := range t.Tasks {
+		cw.Write(csvRow(task))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteHistoryCSV writes a row for every task of every Day in days to w as CSV, with the same
+// columns as TaskList.WriteCSV preceded by the date of the day. Days without a parsed today file
+// are skipped. This is the history of the tasks in a form that can be pivoted in a spreadsheet.
+func WriteHistoryCSV(w io.Writer, days []Day) error {
+	cw := csv.NewWriter(w)
+	cw.Write(append([]string{"date"}, csvHeader...))
+	for _, day := range days {
+		if day.Today == nil {
+			continue
+		}
+		date := day.Date.Format(csvDate)
+		for _, task := range day.Today.Tasks.Tasks {
+			cw.Write(append([]string{date}, csvRow(task)...))
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,39 @@
+package today
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteCSV(t *testing.T) {
+	d := time.Date(2020, 1, 5, 0, 0, 0, 0, time.Local)
+	list := TaskList{Tasks: []*Task{
+		{Name: "JIRA-12", Description: "Fix the thing, finally", Status: Status{Name: "IN PROGRESS", Comment: `pr "#3"`, Date: d}, Comments: []string{"a", "b"}},
+		{Name: "TASK-4", Description: "New"},
+	}}
+	var b bytes.Buffer
+	assert.NoError(t, list.WriteCSV(&b))
+	assert.Equal(t, `name,description,status,status_comment,status_date,comments
+JIRA-12,"Fix the thing, finally",IN PROGRESS,"pr ""#3""",2020-01-05,2
+TASK-4,New,,,,0
+`, b.String())
+}
+
+func TestWriteHistoryCSV(t *testing.T) {
+	d := func(day int) time.Time { return time.Date(2020, 1, day, 0, 0, 0, 0, time.Local) }
+	days := []Day{
+		dayWith(d(1), &Task{Name: "TASK-1", Description: "Fix it", Status: Status{Name: "?", Date: d(1)}}),
+		dayWith(d(2), &Task{Name: "TASK-1", Description: "Fix it", Status: Status{Name: "DONE", Date: d(2)}}, &Task{Name: "TASK-2", Description: "Next"}),
+		{Date: d(3)},
+	}
+	var b bytes.Buffer
+	assert.NoError(t, WriteHistoryCSV(&b, days))
+	assert.Equal(t, `date,name,description,status,status_comment,status_date,comments
+2020-01-01,TASK-1,Fix it,?,,2020-01-01,0
+2020-01-02,TASK-1,Fix it,DONE,,2020-01-02,0
+2020-01-02,TASK-2,Next,,,,0
+`, b.String())
+}
@@ -1,5 +1,8 @@
 module github.com/knusbaum/today
 
-go 1.12
+go 1.16
 
-require github.com/stretchr/testify v1.6.1
+require (
+	github.com/stretchr/testify v1.6.1
+	golang.org/x/term v0.10.0
+)
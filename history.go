@@ -0,0 +1,161 @@
+package today
+
+import (
+	"time"
+)
+
+// A Day is a today file as it was on a particular date. The today program keeps one file per day,
+// and a sequence of Days read from those files, oldest first, records how each task changed over
+// time.
+type Day struct {
+	Date  time.Time
+	Today *Today
+}
+
+// EventKind describes what happened to a task in a TaskEvent.
+type EventKind int
+
+const (
+	// Created means the task appeared for the first time.
+	Created EventKind = iota
+	// StatusChanged means the task's Status name or comment changed.
+	StatusChanged
+	// CommentAdded means a comment was added beneath the task.
+	CommentAdded
+	// CommentRemoved means a comment was removed from beneath the task.
+	CommentRemoved
+	// Completed means the task moved to "DONE".
+	Completed
+	// Removed means the task disappeared from the file without being marked "DONE".
+	Removed
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case Created:
+		return "created"
+	case StatusChanged:
+		return "status"
+	case CommentAdded:
+		return "comment added"
+	case CommentRemoved:
+		return "comment removed"
+	case Completed:
+		return "done"
+	case Removed:
+		return "removed"
+	}
+	return "unknown"
+}
+
+// A TaskEvent is one change to a task between two Days. Status holds the task's Status after the
+// event and Previous holds it before. For CommentAdded and CommentRemoved, Comment is the comment
+// in question.
+type TaskEvent struct {
+	Date        time.Time
+	Kind        EventKind
+	Description string
+	Status      Status
+	Previous    Status
+	Comment     string
+}
+
+// Find returns the task with the given name, or nil if there is no such task.
+func (t *TaskList) Find(name string) *Task {
+	for _, task := range t.Tasks {
+		if task.Name == name {
+			return task
+		}
+	}
+	return nil
+}
+
+// TaskHistory walks days, which must be in date order, and returns the sequence of events that
+// happened to the task with the given name. Consecutive days are compared, so an event is dated
+// with the first Day on which the change was seen.
+//
+// A task that disappears after being marked "DONE" has simply been cleared, so only tasks that
+// disappear while still open produce a Removed event. If such a task shows up again later, it is
+// reported as Created again.
+func TaskHistory(name string, days []Day) []TaskEvent {
+	var (
+		events []TaskEvent
+		prev   *Task
+	)
+	for _, day := range days {
+		if day.Today == nil {
+			continue
+		}
+		cur := day.Today.Tasks.Find(name)
+		switch {
+		case cur == nil && prev == nil:
+		case cur == nil:
+			if prev.Status.Name != "DONE" {
+				events = append(events, TaskEvent{
+					Date:        day.Date,
+					Kind:        Removed,
+					Description: prev.Description,
+					Previous:    prev.Status,
+				})
+			}
+		case prev == nil:
+			events = append(events, TaskEvent{
+				Date:        day.Date,
+				Kind:        Created,
+				Description: cur.Description,
+				Status:      cur.Status,
+			})
+			for _, c := range cur.Comments {
+				events = append(events, TaskEvent{Date: day.Date, Kind: CommentAdded, Description: cur.Description, Status: cur.Status, Comment: c})
+			}
+			if cur.Status.Name == "DONE" {
+				events = append(events, TaskEvent{Date: day.Date, Kind: Completed, Description: cur.Description, Status: cur.Status})
+			}
+		default:
+			events = append(events, diffTask(day.Date, prev, cur)...)
+		}
+		prev = cur
+	}
+	return events
+}
+
+func diffTask(date time.Time, prev, cur *Task) []TaskEvent {
+	var events []TaskEvent
+	if prev.Status.Name != cur.Status.Name || prev.Status.Comment != cur.Status.Comment {
+		kind := StatusChanged
+		if cur.Status.Name == "DONE" {
+			kind = Completed
+		}
+		events = append(events, TaskEvent{
+			Date:        date,
+			Kind:        kind,
+			Description: cur.Description,
+			Status:      cur.Status,
+			Previous:    prev.Status,
+		})
+	}
+
+	// Comments are compared as multisets so that reordering them is not reported as a change.
+	counts := make(map[string]int)
+	for _, c := range prev.Comments {
+		counts[c]++
+	}
+	var added []string
+	for _, c := range cur.Comments {
+		if counts[c] > 0 {
+			counts[c]--
+			continue
+		}
+		added = append(added, c)
+	}
+	for _, c := range prev.Comments {
+		if counts[c] > 0 {
+			counts[c]--
+			events = append(events, TaskEvent{Date: date, Kind: CommentRemoved, Description: cur.Description, Status: cur.Status, Comment: c})
+		}
+	}
+	for _, c := range added {
+		events = append(events, TaskEvent{Date: date, Kind: CommentAdded, Description: cur.Description, Status: cur.Status, Comment: c})
+	}
+	return events
+}
@@ -0,0 +1,90 @@
+package today
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func dayWith(date time.Time, tasks ...*Task) Day {
+	return Day{Date: date, Today: &Today{Tasks: TaskList{Tasks: tasks}}}
+}
+
+func TestTaskHistory(t *testing.T) {
+	d := func(day int) time.Time { return time.Date(2020, 1, day, 0, 0, 0, 0, time.Local) }
+
+	t.Run("lifecycle", func(t *testing.T) {
+		days := []Day{
+			dayWith(d(1)),
+			dayWith(d(2), &Task{Name: "JIRA-881", Description: "Fix it", Status: Status{Name: "?", Date: d(2)}}),
+			dayWith(d(3), &Task{Name: "JIRA-881", Description: "Fix it", Status: Status{Name: "IN PROGRESS", Date: d(3)}, Comments: []string{"step 1"}}),
+			dayWith(d(4), &Task{Name: "JIRA-881", Description: "Fix it", Status: Status{Name: "IN PROGRESS", Date: d(3)}, Comments: []string{"step 2"}}),
+			dayWith(d(5), &Task{Name: "JIRA-881", Description: "Fix it", Status: Status{Name: "DONE", Comment: "merged", Date: d(5)}, Comments: []string{"step 2"}}),
+			dayWith(d(6)),
+		}
+		events := TaskHistory("JIRA-881", days)
+		if !assert.Len(t, events, 6) {
+			return
+		}
+		assert.Equal(t, Created, events[0].Kind)
+		assert.Equal(t, d(2), events[0].Date)
+		assert.Equal(t, "Fix it", events[0].Description)
+
+		assert.Equal(t, StatusChanged, events[1].Kind)
+		assert.Equal(t, "?", events[1].Previous.Name)
+		assert.Equal(t, "IN PROGRESS", events[1].Status.Name)
+
+		// Comment events for a day come after its status change.
+		assert.Equal(t, CommentAdded, events[2].Kind)
+		assert.Equal(t, "step 1", events[2].Comment)
+		assert.Equal(t, d(3), events[2].Date)
+
+		assert.Equal(t, CommentRemoved, events[3].Kind)
+		assert.Equal(t, "step 1", events[3].Comment)
+		assert.Equal(t, d(4), events[3].Date)
+		assert.Equal(t, CommentAdded, events[4].Kind)
+		assert.Equal(t, "step 2", events[4].Comment)
+
+		// Being cleared after DONE is not a removal.
+		assert.Equal(t, Completed, events[5].Kind)
+		assert.Equal(t, "merged", events[5].Status.Comment)
+	})
+
+	t.Run("comment-added", func(t *testing.T) {
+		days := []Day{
+			dayWith(d(1), &Task{Name: "TASK-1", Comments: []string{"a"}}),
+			dayWith(d(2), &Task{Name: "TASK-1", Comments: []string{"a", "b"}}),
+		}
+		events := TaskHistory("TASK-1", days)
+		if !assert.Len(t, events, 3) {
+			return
+		}
+		assert.Equal(t, Created, events[0].Kind)
+		assert.Equal(t, CommentAdded, events[1].Kind)
+		assert.Equal(t, "a", events[1].Comment)
+		assert.Equal(t, CommentAdded, events[2].Kind)
+		assert.Equal(t, "b", events[2].Comment)
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		days := []Day{
+			dayWith(d(1), &Task{Name: "TASK-1", Status: Status{Name: "READY"}}),
+			dayWith(d(2)),
+			dayWith(d(3), &Task{Name: "TASK-1", Status: Status{Name: "READY"}}),
+		}
+		events := TaskHistory("TASK-1", days)
+		if !assert.Len(t, events, 3) {
+			return
+		}
+		assert.Equal(t, Created, events[0].Kind)
+		assert.Equal(t, Removed, events[1].Kind)
+		assert.Equal(t, d(2), events[1].Date)
+		assert.Equal(t, Created, events[2].Kind)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		days := []Day{dayWith(d(1), &Task{Name: "TASK-1"})}
+		assert.Empty(t, TaskHistory("TASK-2", days))
+	})
+}
@@ -0,0 +1,147 @@
+package today
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	icalDate     = "20060102"
+	icalDateTime = "20060102T150405Z"
+)
+
+// icalEscape escapes text for an iCalendar TEXT value.
+var icalEscape = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+// icalWriter writes iCalendar content lines, folding them at 75 octets and ending them with CRLF as
+// RFC 5545 requires.
+type icalWriter struct {
+	w *bufio.Writer
+}
+
+func (iw icalWriter) line(name, value string) {
+	line := name + ":" + value
+	// Continuation lines start with a space, which counts towards their length.
+	for limit := 75; len(line) > limit; limit = 74 {
+		i := limit
+		for !utf8.RuneStart(line[i]) {
+			i--
+		}
+		iw.w.WriteString(line[:i] + "\r\n ")
+		line = line[i:]
+	}
+	iw.w.WriteString(line + "\r\n")
+}
+
+func (iw icalWriter) text(name, value string) {
+	iw.line(name, icalEscape.Replace(value))
+}
+
+// icalUID returns a UID for a task that stays the same as long as the task's name does. Tasks
+// without a name fall back on a hash of the description.
+func icalUID(kind string, task *Task) string {
+	id := task.Name
+	if id == "" {
+		id = fmt.Sprintf("%x", sha1.Sum([]byte(task.Description)))[:16]
+	}
+	return kind + "-" + id + "@today"
+}
+
+// taskDue returns the date in a task's "due: 2006-01-02" comment, if it has one.
+func taskDue(task *Task) (time.Time, bool) {
+	v, ok := Lines(task.Comments).Get("due")
+	if !ok {
+		return time.Time{}, false
+	}
+	due, err := time.ParseInLocation("2006-01-02", v, time.Local)
+	return due, err == nil
+}
+
+func taskSummary(task *Task) string {
+	if task.Name == "" {
+		return task.Description
+	}
+	return task.Name + " " + task.Description
+}
+
+// WriteICal writes the open tasks of t to w as an iCalendar file. Each task that isn't "DONE"
+// becomes a VTODO. Its STATUS is IN-PROCESS for "IN PROGRESS" tasks and NEEDS-ACTION for the rest,
+// the today status is kept as its category, and the status and comments make up the description.
+// A task with a "due: 2006-01-02" comment gets that as its DUE date.
+//
+// "HOLD" tasks also get an all-day VEVENT on the day they come off hold, and tasks with a due date
+// an all-day VEVENT on that day, so both show up in calendar apps that don't show to-dos. UIDs are
+// made from task names, so that a calendar subscribed to the file updates its events rather than
+// duplicating them.
+func (t *TaskList) WriteICal(w io.Writer) error {
+	wtr := bufio.NewWriter(w)
+	iw := icalWriter{wtr}
+	now := time.Now().UTC().Format(icalDateTime)
+
+	iw.line("BEGIN", "VCALENDAR")
+	iw.line("VERSION", "2.0")
+	iw.line("PRODID", "-//knusbaum//today//EN")
+	for _, task := range t.Tasks {
+		if task.Status.Name == "DONE" {
+			continue
+		}
+		description := task.Status.Name
+		if task.Status.Comment != "" {
+			description += " - " + task.Status.Comment
+		}
+		for _, c := range task.Comments {
+			description += "\n" + c
+		}
+		due, hasDue := taskDue(task)
+
+		iw.line("BEGIN", "VTODO")
+		iw.line("UID", icalUID("task", task))
+		iw.line("DTSTAMP", now)
+		// The date of a HOLD is when it will be released, not when it was last changed.
+		if !task.Status.Date.IsZero() && task.Status.Name != "HOLD" {
+			iw.line("LAST-MODIFIED", task.Status.Date.UTC().Format(icalDateTime))
+		}
+		iw.text("SUMMARY", taskSummary(task))
+		iw.text("DESCRIPTION", description)
+		if priorityOrder[task.Status.Name] == priorityOrder["IN PROGRESS"] {
+			iw.line("STATUS", "IN-PROCESS")
+		} else {
+			iw.line("STATUS", "NEEDS-ACTION")
+		}
+		if !task.Status.isUnknown() {
+			iw.text("CATEGORIES", task.Status.Name)
+		}
+		if p := todoTxtPriority(task.Status.Name); p != 0 {
+			iw.line("PRIORITY", fmt.Sprint(int(p-'A')+1))
+		}
+		if hasDue {
+			iw.line("DUE;VALUE=DATE", due.Format(icalDate))
+		}
+		iw.line("END", "VTODO")
+
+		event := func(kind string, date time.Time, summary string) {
+			iw.line("BEGIN", "VEVENT")
+			iw.line("UID", icalUID(kind, task))
+			iw.line("DTSTAMP", now)
+			iw.line("DTSTART;VALUE=DATE", date.Format(icalDate))
+			iw.line("DTEND;VALUE=DATE", date.AddDate(0, 0, 1).Format(icalDate))
+			iw.text("SUMMARY", summary)
+			iw.text("DESCRIPTION", description)
+			iw.line("TRANSP", "TRANSPARENT")
+			iw.line("END", "VEVENT")
+		}
+		if task.Status.Name == "HOLD" && !task.Status.Date.IsZero() {
+			event("hold", task.Status.Date, "Off hold: "+taskSummary(task))
+		}
+		if hasDue {
+			event("due", due, "Due: "+taskSummary(task))
+		}
+	}
+	iw.line("END", "VCALENDAR")
+	return wtr.Flush()
+}
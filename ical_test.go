@@ -0,0 +1,81 @@
+package today
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteICal(t *testing.T) {
+	d := time.Date(2020, 1, 5, 0, 0, 0, 0, time.Local)
+	list := TaskList{Tasks: []*Task{
+		{Name: "JIRA-12", Description: "Fix the thing, finally", Status: Status{Name: "IN PROGRESS", Comment: "pr #3", Date: d}, Comments: []string{"due: 2020-01-10", "step 1"}},
+		{Name: "TASK-4", Description: "Later", Status: Status{Name: "HOLD", Date: d.AddDate(0, 0, 7)}},
+		{Name: "TASK-6", Description: "Write docs", Status: Status{Name: "DONE", Date: d}},
+	}}
+
+	var b bytes.Buffer
+	assert.NoError(t, list.WriteICal(&b))
+	out := regexp.MustCompile(`DTSTAMP:[0-9TZ]+`).ReplaceAllString(b.String(), "DTSTAMP:x")
+	assert.Equal(t, strings.Replace(`BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//knusbaum//today//EN
+BEGIN:VTODO
+UID:task-JIRA-12@today
+DTSTAMP:x
+LAST-MODIFIED:`+d.UTC().Format(icalDateTime)+`
+SUMMARY:JIRA-12 Fix the thing\, finally
+DESCRIPTION:IN PROGRESS - pr #3\ndue: 2020-01-10\nstep 1
+STATUS:IN-PROCESS
+CATEGORIES:IN PROGRESS
+PRIORITY:1
+DUE;VALUE=DATE:20200110
+END:VTODO
+BEGIN:VEVENT
+UID:due-JIRA-12@today
+DTSTAMP:x
+DTSTART;VALUE=DATE:20200110
+DTEND;VALUE=DATE:20200111
+SUMMARY:Due: JIRA-12 Fix the thing\, finally
+DESCRIPTION:IN PROGRESS - pr #3\ndue: 2020-01-10\nstep 1
+TRANSP:TRANSPARENT
+END:VEVENT
+BEGIN:VTODO
+UID:task-TASK-4@today
+DTSTAMP:x
+SUMMARY:TASK-4 Later
+DESCRIPTION:HOLD
+STATUS:NEEDS-ACTION
+CATEGORIES:HOLD
+PRIORITY:6
+END:VTODO
+BEGIN:VEVENT
+UID:hold-TASK-4@today
+DTSTAMP:x
+DTSTART;VALUE=DATE:20200112
+DTEND;VALUE=DATE:20200113
+SUMMARY:Off hold: TASK-4 Later
+DESCRIPTION:HOLD
+TRANSP:TRANSPARENT
+END:VEVENT
+END:VCALENDAR
+`, "\n", "\r\n", -1), out)
+}
+
+func TestICalFolding(t *testing.T) {
+	var b bytes.Buffer
+	iw := icalWriter{bufio.NewWriter(&b)}
+	iw.text("SUMMARY", strings.Repeat("é", 50))
+	iw.w.Flush()
+	for _, l := range strings.Split(b.String(), "\r\n") {
+		assert.True(t, len(l) <= 75)
+	}
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n ")
+	assert.Len(t, lines, 2)
+	assert.Equal(t, "SUMMARY:"+strings.Repeat("é", 50), strings.Join(lines, ""))
+}
@@ -0,0 +1,183 @@
+// Package jsonrpc serves JSON-RPC 2.0 over a stream, with each message framed by a Content-Length
+// header as in the Language Server Protocol:
+//
+//	Content-Length: 52\r\n
+//	\r\n
+//	{"jsonrpc":"2.0","id":1,"method":"tasks.list"}
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Error codes defined by JSON-RPC 2.0.
+const (
+	ParseError     = -32700
+	InvalidRequest = -32600
+	MethodNotFound = -32601
+	InvalidParams  = -32602
+	InternalError  = -32603
+)
+
+// Error is a JSON-RPC error. Handlers may return one to choose the code sent to the client; other
+// errors are sent as InternalError.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Errorf returns an *Error with the given code and formatted message.
+func Errorf(code int, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// message is any JSON-RPC message: a request, a notification (a request without an ID) or a
+// response.
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  interface{}      `json:"result,omitempty"`
+	Error   *Error           `json:"error,omitempty"`
+}
+
+// maxMessageSize is the largest message body ReadMessage will read.
+const maxMessageSize = 16 << 20
+
+// ReadMessage reads the body of the next message from r. Messages larger than 16MB are refused, so
+// that a bad Content-Length can't make it allocate any amount of memory.
+func ReadMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF && len(header) == 0 {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("bad Content-Length %q", header.Get("Content-Length"))
+	}
+	if length > maxMessageSize {
+		return nil, fmt.Errorf("message of %d bytes is too large", length)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// WriteMessage writes body to w as a single message.
+func WriteMessage(w io.Writer, body []byte) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Content-Length: %d\r\n\r\n", len(body))
+	b.Write(body)
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// Handler handles a request or notification. params holds the raw parameters, which may be empty.
+// The result is sent to the client as JSON, unless the request was a notification.
+type Handler func(method string, params json.RawMessage) (interface{}, error)
+
+// Conn is a JSON-RPC connection.
+type Conn struct {
+	r  *bufio.Reader
+	w  io.Writer
+	mu sync.Mutex
+}
+
+// NewConn returns a Conn that reads messages from r and writes them to w.
+func NewConn(r io.Reader, w io.Writer) *Conn {
+	return &Conn{r: bufio.NewReader(r), w: w}
+}
+
+func (c *Conn) send(m *message) error {
+	m.JSONRPC = "2.0"
+	body, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return WriteMessage(c.w, body)
+}
+
+// Notify sends a notification to the client. It may be called while Serve is running.
+func (c *Conn) Notify(method string, params interface{}) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return c.send(&message{Method: method, Params: raw})
+}
+
+// Serve reads requests from the connection and answers them with h, one at a time, until the client
+// disconnects. Responses sent by the client are ignored.
+func (c *Conn) Serve(h Handler) error {
+	null := json.RawMessage("null")
+	for {
+		body, err := ReadMessage(c.r)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		var m message
+		if strings.HasPrefix(strings.TrimSpace(string(body)), "[") {
+			err = c.send(&message{ID: &null, Error: Errorf(InvalidRequest, "batches are not supported")})
+		} else if jerr := json.Unmarshal(body, &m); jerr != nil {
+			err = c.send(&message{ID: &null, Error: Errorf(ParseError, "%v", jerr)})
+		} else if m.Method == "" {
+			// A response to something we sent, which we never wait for.
+			continue
+		} else {
+			result, herr := h(m.Method, m.Params)
+			if m.ID == nil {
+				continue
+			}
+			resp := &message{ID: m.ID}
+			if herr != nil {
+				e, ok := herr.(*Error)
+				if !ok {
+					e = &Error{Code: InternalError, Message: herr.Error()}
+				}
+				resp.Error = e
+			} else {
+				if result == nil {
+					result = null
+				}
+				resp.Result = result
+			}
+			err = c.send(resp)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Params decodes params into v, returning an InvalidParams error if they don't fit.
+func Params(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return Errorf(InvalidParams, "%v", err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadMessage(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("Content-Length: 2\r\nContent-Type: application/vscode-jsonrpc; charset=utf-8\r\n\r\n{}" +
+		"Content-Length: 7\r\n\r\n[1,2,3]"))
+	body, err := ReadMessage(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "{}", string(body))
+	body, err = ReadMessage(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "[1,2,3]", string(body))
+	_, err = ReadMessage(r)
+	assert.Equal(t, io.EOF, err)
+
+	_, err = ReadMessage(bufio.NewReader(strings.NewReader("Content-Type: text/plain\r\n\r\n{}")))
+	assert.NotNil(t, err)
+	_, err = ReadMessage(bufio.NewReader(strings.NewReader("Content-Length: 4611686018427387904\r\n\r\n{}")))
+	assert.EqualError(t, err, "message of 4611686018427387904 bytes is too large")
+}
+
+// client is the other end of a Conn being served.
+type client struct {
+	t *testing.T
+	r *bufio.Reader
+	w io.Writer
+}
+
+func serve(t *testing.T, h Handler) (*Conn, *client, chan error) {
+	cr, sw := io.Pipe()
+	sr, cw := io.Pipe()
+	c := NewConn(sr, sw)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Serve(h)
+		sw.Close()
+	}()
+	return c, &client{t, bufio.NewReader(cr), cw}, done
+}
+
+func (c *client) send(msg string) {
+	if err := WriteMessage(c.w, []byte(msg)); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *client) recv() map[string]interface{} {
+	body, err := ReadMessage(c.r)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(body, &m); err != nil {
+		c.t.Fatal(err)
+	}
+	return m
+}
+
+func TestServe(t *testing.T) {
+	var notified []string
+	conn, c, done := serve(t, func(method string, params json.RawMessage) (interface{}, error) {
+		switch method {
+		case "add":
+			var p struct{ A, B int }
+			if err := Params(params, &p); err != nil {
+				return nil, err
+			}
+			return p.A + p.B, nil
+		case "nothing":
+			return nil, nil
+		case "fail":
+			return nil, errors.New("it failed")
+		case "hello":
+			notified = append(notified, string(params))
+			return nil, nil
+		}
+		return nil, Errorf(MethodNotFound, "no method %s", method)
+	})
+
+	c.send(`{"jsonrpc":"2.0","id":1,"method":"add","params":{"a":2,"b":3}}`)
+	assert.Equal(t, map[string]interface{}{"jsonrpc": "2.0", "id": 1.0, "result": 5.0}, c.recv())
+
+	c.send(`{"jsonrpc":"2.0","id":"x","method":"nothing"}`)
+	assert.Equal(t, map[string]interface{}{"jsonrpc": "2.0", "id": "x", "result": nil}, c.recv())
+
+	// Notifications aren't answered.
+	c.send(`{"jsonrpc":"2.0","method":"hello","params":["world"]}`)
+
+	c.send(`{"jsonrpc":"2.0","id":2,"method":"add","params":{"a":"two"}}`)
+	m := c.recv()
+	assert.Equal(t, 2.0, m["id"])
+	assert.Equal(t, float64(InvalidParams), m["error"].(map[string]interface{})["code"])
+
+	c.send(`{"jsonrpc":"2.0","id":3,"method":"fail"}`)
+	assert.Equal(t, map[string]interface{}{"code": float64(InternalError), "message": "it failed"}, c.recv()["error"])
+
+	c.send(`{"jsonrpc":"2.0","id":4,"method":"nope"}`)
+	assert.Equal(t, float64(MethodNotFound), c.recv()["error"].(map[string]interface{})["code"])
+
+	c.send(`{"jsonrpc":`)
+	m = c.recv()
+	assert.Nil(t, m["id"])
+	assert.Equal(t, float64(ParseError), m["error"].(map[string]interface{})["code"])
+
+	// Responses from the client are ignored.
+	c.send(`{"jsonrpc":"2.0","id":9,"result":true}`)
+
+	go conn.Notify("changed", map[string]string{"file": "today"})
+	assert.Equal(t, map[string]interface{}{"jsonrpc": "2.0", "method": "changed", "params": map[string]interface{}{"file": "today"}}, c.recv())
+
+	c.w.(io.Closer).Close()
+	assert.Nil(t, <-done)
+	assert.Equal(t, []string{`["world"]`}, notified)
+}
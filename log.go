@@ -0,0 +1,189 @@
+package today
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// LogKind describes what a LogEntry records.
+type LogKind int
+
+const (
+	// LogText is a line of the Log without a timestamp.
+	LogText LogKind = iota
+	// LogManual is a timestamped entry added by hand or with the today log command.
+	LogManual
+	// LogStatus is a task status change recorded by TaskList.Update.
+	LogStatus
+	// LogStartup is a Startup item status change recorded by List.UpdateLog.
+	LogStartup
+)
+
+func (k LogKind) String() string {
+	switch k {
+	case LogText:
+		return "text"
+	case LogManual:
+		return "manual"
+	case LogStatus:
+		return "status"
+	case LogStartup:
+		return "startup"
+	}
+	return "unknown"
+}
+
+// logTimeFormat is the format of timestamps on new LogEntries.
+const logTimeFormat = "3:04PM"
+
+// logTimeFormats are the timestamp formats recognized when parsing a Log. Older today files use
+// "3:04", without AM or PM.
+var logTimeFormats = []string{"3:04PM", "3:04pm", "3:04 PM", "3:04 pm", "3:04", "15:04"}
+
+var (
+	logLineRe   = regexp.MustCompile(`^([0-9]{1,2}:[0-9]{2}(?:[[:space:]]?[AaPp][Mm])?)([[:space:]]+-[[:space:]]+|[[:space:]]+)(.*)$`)
+	logStatusRe = regexp.MustCompile(`^Moved ([A-Z]+-[0-9]+) \(`)
+	logTaskRe   = regexp.MustCompile(`\b[A-Z]+-[0-9]+\b`)
+)
+
+// A LogEntry is a single line of the Log section. Log lines usually begin with a time of day,
+// followed by a hyphen and a message:
+//   4:48PM - Moved TASK-123 (Do something important) to DONE (Finished up)
+//
+// Time holds the time of the entry. Since a today file covers a single day, the date comes from
+// the day the Log belongs to. Kind is worked out from the message, and Task is the name of the task
+// the entry refers to, if any. Lines without a timestamp have Kind LogText and a zero Time.
+//
+// Parsing a line and calling String gives back the same line, whichever of the accepted time
+// formats it was written with.
+type LogEntry struct {
+	Time    time.Time
+	Kind    LogKind
+	Task    string
+	Message string
+
+	layout string
+	sep    string
+}
+
+// NewLogEntry returns a LogEntry with the given message, timestamped with the current time.
+func NewLogEntry(message string) LogEntry {
+	return NewLogEntryAt(time.Now(), message)
+}
+
+// NewLogEntryAt returns a LogEntry with the given message, timestamped with t.
+func NewLogEntryAt(t time.Time, message string) LogEntry {
+	e := LogEntry{Time: t, Message: message, layout: logTimeFormat, sep: " - "}
+	e.Kind, e.Task = classifyLog(message)
+	return e
+}
+
+// ParseLogEntry parses a line of the Log section belonging to the today file for day.
+func ParseLogEntry(line string, day time.Time) LogEntry {
+	m := logLineRe.FindStringSubmatch(line)
+	if m == nil {
+		return LogEntry{Kind: LogText, Message: line}
+	}
+	for _, layout := range logTimeFormats {
+		t, err := time.Parse(layout, m[1])
+		if err != nil || t.Format(layout) != m[1] {
+			continue
+		}
+		y, mo, d := day.Date()
+		e := LogEntry{
+			Time:    time.Date(y, mo, d, t.Hour(), t.Minute(), 0, 0, time.Local),
+			Message: m[3],
+			layout:  layout,
+			sep:     m[2],
+		}
+		e.Kind, e.Task = classifyLog(e.Message)
+		return e
+	}
+	return LogEntry{Kind: LogText, Message: line}
+}
+
+func classifyLog(message string) (LogKind, string) {
+	if strings.HasPrefix(message, "Completed startup item ") || strings.HasPrefix(message, "Moved startup item ") {
+		return LogStartup, ""
+	}
+	if m := logStatusRe.FindStringSubmatch(message); m != nil {
+		return LogStatus, m[1]
+	}
+	return LogManual, logTaskRe.FindString(message)
+}
+
+// String formats the entry as a line of the Log section.
+func (e LogEntry) String() string {
+	if e.Time.IsZero() {
+		return e.Message
+	}
+	layout, sep := e.layout, e.sep
+	if layout == "" {
+		layout = logTimeFormat
+	}
+	if sep == "" {
+		sep = " - "
+	}
+	return e.Time.Format(layout) + sep + e.Message
+}
+
+// Entries parses every line of l as a LogEntry. day is the date of the today file l belongs to.
+//
+// Times in the older "3:04" format don't say whether they are AM or PM. Since the Log is kept in
+// time order, one that is earlier than the entry before it is taken to be PM.
+func (l Lines) Entries(day time.Time) []LogEntry {
+	entries := make([]LogEntry, 0, len(l))
+	var prev time.Time
+	for _, line := range l {
+		e := ParseLogEntry(line, day)
+		if e.layout == "3:04" && e.Time.Hour() < 12 && e.Time.Before(prev) {
+			e.Time = e.Time.Add(12 * time.Hour)
+		}
+		if !e.Time.IsZero() {
+			prev = e.Time
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// Append adds a LogEntry to the end of l.
+func (l *Lines) Append(e LogEntry) {
+	l.Add(e.String())
+}
+
+// Insert adds a LogEntry to l in time order: before the first entry with a later time, or otherwise
+// after the last non-blank line. The entry is given the same time format and separator as the last
+// timestamped entry already in l, so that it matches the rest of the Log. Insert returns false
+// without changing l if an entry with the same time and message is already there, or if the line
+// it would add is already there, as happens with times in the "3:04" format that Entries can't tell
+// are PM. day is the date of the today file l belongs to.
+func (l *Lines) Insert(e LogEntry, day time.Time) bool {
+	// Log times only go down to the minute.
+	e.Time = e.Time.Truncate(time.Minute)
+	pos := -1
+	last := -1
+	for i, existing := range l.Entries(day) {
+		if strings.TrimSpace(existing.Message) != "" {
+			last = i
+		}
+		if existing.Time.IsZero() {
+			continue
+		}
+		e.layout, e.sep = existing.layout, existing.sep
+		if (existing.Time.Equal(e.Time) && existing.Message == e.Message) || (*l)[i] == e.String() {
+			return false
+		}
+		if pos < 0 && existing.Time.After(e.Time) {
+			pos = i
+		}
+	}
+	if pos < 0 {
+		pos = last + 1
+	}
+	*l = append(*l, "")
+	copy((*l)[pos+1:], (*l)[pos:])
+	(*l)[pos] = e.String()
+	return true
+}
@@ -0,0 +1,53 @@
+package today
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLogEntry(t *testing.T) {
+	day := time.Date(2020, 1, 5, 0, 0, 0, 0, time.Local)
+	at := func(h, m int) time.Time { return time.Date(2020, 1, 5, h, m, 0, 0, time.Local) }
+
+	for _, tc := range []struct {
+		line    string
+		time    time.Time
+		kind    LogKind
+		task    string
+		message string
+	}{
+		{"4:48 - Moved TASK-123 (Do something important) to DONE (Finished up)", at(4, 48), LogStatus, "TASK-123", "Moved TASK-123 (Do something important) to DONE (Finished up)"},
+		{"4:48PM - Moved JIRA-9 (Fix it) to  IN PROGRESS", at(16, 48), LogStatus, "JIRA-9", "Moved JIRA-9 (Fix it) to  IN PROGRESS"},
+		{"9:12AM - Completed startup item 2 (Check the calendar)", at(9, 12), LogStartup, "", "Completed startup item 2 (Check the calendar)"},
+		{"8:30 Starting work", at(8, 30), LogManual, "", "Starting work"},
+		{"14:05 - Paired with bob on JIRA-12", at(14, 5), LogManual, "JIRA-12", "Paired with bob on JIRA-12"},
+		{"09:15 - Standup", at(9, 15), LogManual, "", "Standup"},
+		{"12:30 pm - lunch", at(12, 30), LogManual, "", "lunch"},
+		{"remember to eat", time.Time{}, LogText, "", "remember to eat"},
+		{"99:99 - nonsense", time.Time{}, LogText, "", "99:99 - nonsense"},
+	} {
+		e := ParseLogEntry(tc.line, day)
+		assert.Equal(t, tc.time, e.Time, tc.line)
+		assert.Equal(t, tc.kind, e.Kind, tc.line)
+		assert.Equal(t, tc.task, e.Task, tc.line)
+		assert.Equal(t, tc.message, e.Message, tc.line)
+		// Every line round-trips.
+		assert.Equal(t, tc.line, e.String())
+	}
+}
+
+func TestNewLogEntry(t *testing.T) {
+	e := NewLogEntry("deployed v2 to staging")
+	assert.Equal(t, LogManual, e.Kind)
+	assert.Regexp(t, `^[0-9]+:[0-9]{2}(AM|PM) - deployed v2 to staging$`, e.String())
+
+	var l Lines
+	l.Append(e)
+	entries := l.Entries(e.Time)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, e.Time.Truncate(time.Minute).Format(time.Kitchen), entries[0].Time.Format(time.Kitchen))
+		assert.Equal(t, "deployed v2 to staging", entries[0].Message)
+	}
+}
@@ -0,0 +1,224 @@
+package today
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// markdownDate formats a status date for Markdown. The year is left off for dates in the current
+// year to keep lines short.
+func markdownDate(date time.Time) string {
+	if date.Year() == time.Now().Year() {
+		return date.Format("Jan 2")
+	}
+	return date.Format("Jan 2, 2006")
+}
+
+// markdownStatus formats a Status the way it follows a task in Markdown:
+//   IN PROGRESS (Working on pr #12), Jan 16
+func markdownStatus(s *Status) string {
+	str := s.Name
+	if s.Comment != "" {
+		str += " (" + s.Comment + ")"
+	}
+	if !s.Date.IsZero() {
+		str += ", " + markdownDate(s.Date)
+	}
+	return str
+}
+
+func markdownCheck(s *Status) string {
+	if s.Name == "DONE" {
+		return "- [x] "
+	}
+	return "- [ ] "
+}
+
+// WriteMarkdown writes a Today out to writer w as Markdown, with a section for each part of the
+// today file. Startup items and tasks become GitHub-style task lists, and task comments become
+// nested bullets:
+//   - [ ] **JIRA-12** Description — _IN PROGRESS (comment), Jan 5_
+//     - Comment 1
+// Tasks marked "DONE" are checked. (See ParseMarkdownTasks)
+func (t *Today) WriteMarkdown(w io.Writer) error {
+	wtr := bufio.NewWriter(w)
+
+	wtr.WriteString("## " + strings.TrimSuffix(startupLine, ":") + "\n\n")
+	for _, item := range t.Startup {
+		wtr.WriteString(markdownCheck(&item.Status) + item.Description)
+		if item.Schedule != "" {
+			wtr.WriteString(" _(" + item.Schedule + ")_")
+		}
+		if item.Status.Name != "" && item.Status.Name != "DONE" {
+			wtr.WriteString(" — _" + markdownStatus(&item.Status) + "_")
+		}
+		wtr.WriteString("\n")
+	}
+
+	wtr.WriteString("\n## " + strings.TrimSuffix(notesLine, ":") + "\n\n")
+	for _, n := range t.Notes {
+		if strings.TrimSpace(n) != "" {
+			wtr.WriteString("- " + strings.TrimSpace(n) + "\n")
+		}
+	}
+
+	wtr.WriteString("\n## " + strings.TrimSuffix(logLine, ":") + "\n\n")
+	for _, l := range t.Log {
+		wtr.WriteString("- " + l + "\n")
+	}
+
+	wtr.WriteString("\n## " + strings.TrimSuffix(todoLine, ":") + "\n\n")
+	for _, task := range t.Tasks.Tasks {
+		wtr.WriteString(markdownCheck(&task.Status))
+		if task.Name != "" {
+			wtr.WriteString("**" + task.Name + "** ")
+		}
+		wtr.WriteString(task.Description)
+		if task.Status.Name != "" || task.Status.Comment != "" {
+			wtr.WriteString(" — _" + markdownStatus(&task.Status) + "_")
+		}
+		wtr.WriteString("\n")
+		for _, c := range task.Comments {
+			wtr.WriteString("  - " + c + "\n")
+		}
+	}
+	return wtr.Flush()
+}
+
+var (
+	mdHeadingRe = regexp.MustCompile(`^#+[[:space:]]+(.*?)[[:space:]]*$`)
+	mdTaskRe    = regexp.MustCompile(`^[-*+] \[([ xX])\] (\*\*([A-Z]+-[0-9]+)\*\* )?(.*?)( — _(.*)_)?$`)
+	mdCommentRe = regexp.MustCompile(`^[[:space:]]+[-*+] (.*)$`)
+	mdStatusRe  = regexp.MustCompile(`^([A-Z-? ]*?)( \((.*)\))?(, ([A-Z][a-z]{2} [0-9]{1,2}(, [0-9]{4})?))?$`)
+)
+
+// parseMarkdownStatus parses a status written by markdownStatus.
+func parseMarkdownStatus(s string) Status {
+	m := mdStatusRe.FindStringSubmatch(s)
+	if m == nil {
+		return Status{Comment: s}
+	}
+	status := Status{Name: m[1], Comment: m[3]}
+	if m[5] == "" {
+		return status
+	}
+	if m[6] != "" {
+		status.Date, _ = time.ParseInLocation("Jan 2, 2006", m[5], time.Local)
+		return status
+	}
+	date, err := time.ParseInLocation("Jan 2", m[5], time.Local)
+	if err == nil {
+		status.Date = time.Date(time.Now().Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+	}
+	return status
+}
+
+// ParseMarkdownTasks parses the tasks out of a Markdown task list, such as one written by
+// WriteMarkdown and then edited. If the document has headings, only the task list under the "TODO"
+// heading is read. Checking a task marks it "DONE", and unchecking a "DONE" task makes it "?"
+// again. The tasks can be merged back into a TaskList with TaskList.Merge.
+func ParseMarkdownTasks(r io.Reader) ([]*Task, error) {
+	var (
+		tasks    []*Task
+		headings bool
+		inTasks  = true
+		last     *Task
+	)
+	todoHeading := strings.TrimSuffix(todoLine, ":")
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		if m := mdHeadingRe.FindStringSubmatch(line); m != nil {
+			if !headings {
+				// Tasks before the first heading don't count once there are headings.
+				headings = true
+				tasks = nil
+			}
+			inTasks = strings.EqualFold(m[1], todoHeading) || strings.EqualFold(m[1], "Tasks")
+			last = nil
+			continue
+		}
+		if !inTasks {
+			continue
+		}
+		if m := mdTaskRe.FindStringSubmatch(line); m != nil {
+			last = &Task{Name: m[3], Description: strings.TrimSpace(m[4])}
+			if m[5] != "" {
+				last.Status = parseMarkdownStatus(m[6])
+			}
+			checked := m[1] != " "
+			if checked && last.Status.Name != "DONE" {
+				last.Status = Status{Name: "DONE"}
+			} else if !checked && last.Status.Name == "DONE" {
+				last.Status = Status{Name: "?"}
+			}
+			tasks = append(tasks, last)
+			continue
+		}
+		if m := mdCommentRe.FindStringSubmatch(line); m != nil && last != nil {
+			last.Comments = append(last.Comments, strings.TrimSpace(m[1]))
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			last = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// Merge merges tasks, such as ones imported from another format, into t. Tasks are matched by Name,
+// or, for imported tasks without a Name, by a keyed "uuid" comment, so that tasks from tools that
+// identify tasks by UUID aren't added twice. (See Lines.Get)
+//
+// A matching task takes the imported task's description and comments. If its Status name or
+// comment changed, it gets the imported Status without its date, so that the next call to Update
+// dates and logs the change, unless the new Status is a "HOLD" with a release date still to come.
+// A "HOLD" that was moved to another date takes the new date. Tasks that don't match any existing
+// task are appended.
+func (t *TaskList) Merge(tasks []*Task) {
+	for _, in := range tasks {
+		var existing *Task
+		if in.Name != "" {
+			existing = t.Find(in.Name)
+		} else if uuid, ok := Lines(in.Comments).Get("uuid"); ok {
+			for _, task := range t.Tasks {
+				if u, ok := Lines(task.Comments).Get("uuid"); ok && u == uuid {
+					existing = task
+					break
+				}
+			}
+		}
+		if existing == nil {
+			t.Tasks = append(t.Tasks, in)
+			if strings.HasPrefix(in.Name, "TASK-") {
+				id, err := strconv.Atoi(strings.TrimPrefix(in.Name, "TASK-"))
+				if err == nil && id >= t.nextTaskID {
+					t.nextTaskID = id + 1
+				}
+			}
+			continue
+		}
+		if in.Description != "" {
+			existing.Description = in.Description
+		}
+		existing.Comments = in.Comments
+		switch {
+		case in.Status.Name != existing.Status.Name || in.Status.Comment != existing.Status.Comment:
+			existing.Status = in.Status
+			// Importers read back the date of the status a task had before, so only a HOLD's
+			// release date is kept. Other changes are left undated for Update to date and log.
+			if in.Status.Name != "HOLD" || !in.Status.Date.After(time.Now()) {
+				existing.Status.Date = time.Time{}
+			}
+		case in.Status.Name == "HOLD" && !in.Status.Date.IsZero() && !sameDay(in.Status.Date, existing.Status.Date):
+			existing.Status.Date = in.Status.Date
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package today
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	d := time.Date(2019, 1, 5, 0, 0, 0, 0, time.Local)
+	today := &Today{
+		Startup: List{
+			{Description: "Check email", Status: Status{Name: "DONE", Date: d}},
+			{Description: "Plan the week", Schedule: "mon"},
+		},
+		Notes: Lines{"deploy: make deploy", ""},
+		Log:   Lines{"8:30AM Started"},
+		Tasks: TaskList{Tasks: []*Task{
+			{Name: "JIRA-12", Description: "Fix the thing", Status: Status{Name: "IN PROGRESS", Comment: "pr #3", Date: d}, Comments: []string{"Comment 1"}},
+			{Name: "TASK-4", Description: "Write docs", Status: Status{Name: "DONE", Date: d}},
+		}},
+	}
+	var b bytes.Buffer
+	assert.NoError(t, today.WriteMarkdown(&b))
+	assert.Equal(t, `## Morning Start Up
+
+- [x] Check email
+- [ ] Plan the week _(mon)_
+
+## Notes
+
+- deploy: make deploy
+
+## Log
+
+- 8:30AM Started
+
+## TODO
+
+- [ ] **JIRA-12** Fix the thing — _IN PROGRESS (pr #3), Jan 5, 2019_
+  - Comment 1
+- [x] **TASK-4** Write docs — _DONE, Jan 5, 2019_
+`, b.String())
+
+	tasks, err := ParseMarkdownTasks(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, today.Tasks.Tasks, tasks)
+}
+
+func TestParseMarkdownTasks(t *testing.T) {
+	tasks, err := ParseMarkdownTasks(strings.NewReader(`# Standup
+
+- [ ] not a task, no TODO heading
+
+## TODO
+
+- [x] **JIRA-12** Fix the thing — _IN PROGRESS (pr #3), Jan 5_
+  - Comment 1
+  - Comment 2
+* [ ] **TASK-4** Write docs — _DONE, Jan 5, 2019_
+- [ ] Brand new task
+
+Some text.
+  - not a comment
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, []*Task{
+		{Name: "JIRA-12", Description: "Fix the thing", Status: Status{Name: "DONE"}, Comments: []string{"Comment 1", "Comment 2"}},
+		{Name: "TASK-4", Description: "Write docs", Status: Status{Name: "?"}},
+		{Description: "Brand new task"},
+	}, tasks)
+}
+
+func TestMerge(t *testing.T) {
+	d := time.Date(2020, 1, 5, 0, 0, 0, 0, time.Local)
+	release := time.Now().AddDate(0, 1, 0).Truncate(time.Second)
+	list := TaskList{Tasks: []*Task{
+		{Name: "JIRA-12", Description: "Fix the thing", Status: Status{Name: "IN PROGRESS", Date: d}, Comments: []string{"a"}},
+		{Name: "TASK-4", Description: "Write docs", Status: Status{Name: "READY", Date: d}},
+		{Name: "TASK-5", Description: "Wait for it", Status: Status{Name: "READY", Date: d}},
+		{Name: "TASK-6", Description: "Held", Status: Status{Name: "HOLD", Date: d.AddDate(0, 0, 7)}},
+	}, nextTaskID: 5}
+
+	list.Merge([]*Task{
+		{Name: "JIRA-12", Description: "Fix the thing", Status: Status{Name: "DONE"}, Comments: []string{"a", "b"}},
+		{Name: "TASK-4", Description: "Write the docs", Status: Status{Name: "READY", Date: d.AddDate(0, 0, 1)}},
+		{Name: "TASK-5", Description: "Wait for it", Status: Status{Name: "HOLD", Date: release}},
+		{Name: "TASK-6", Description: "Held", Status: Status{Name: "HOLD", Date: d.AddDate(0, 0, 14)}},
+		{Name: "TASK-9", Description: "Imported"},
+		{Description: "New"},
+	})
+	assert.Equal(t, Status{Name: "DONE"}, list.Tasks[0].Status)
+	assert.Equal(t, []string{"a", "b"}, list.Tasks[0].Comments)
+	assert.Equal(t, Status{Name: "READY", Date: d}, list.Tasks[1].Status)
+	assert.Equal(t, "Write the docs", list.Tasks[1].Description)
+	// A HOLD keeps its release date, so it isn't released right away.
+	assert.Equal(t, Status{Name: "HOLD", Date: release}, list.Tasks[2].Status)
+	assert.Equal(t, Status{Name: "HOLD", Date: d.AddDate(0, 0, 14)}, list.Tasks[3].Status)
+	assert.Len(t, list.Tasks, 6)
+	assert.Equal(t, 10, list.nextTaskID)
+}
+
+func TestMergeLogsChanges(t *testing.T) {
+	d := time.Date(2020, 1, 5, 0, 0, 0, 0, time.Local)
+	list := TaskList{Tasks: []*Task{
+		{Name: "JIRA-12", Description: "Fix the thing", Status: Status{Name: "IN PROGRESS", Date: d}},
+	}}
+	// Importers read back the date the old status had.
+	list.Merge([]*Task{{Name: "JIRA-12", Description: "Fix the thing", Status: Status{Name: "DONE", Date: d}}})
+	var log Lines
+	list.Update(&log)
+	assert.Equal(t, "DONE", list.Tasks[0].Status.Name)
+	assert.True(t, sameDay(time.Now(), list.Tasks[0].Status.Date))
+	if assert.Len(t, log, 1) {
+		assert.Contains(t, log[0], "JIRA-12")
+		assert.Contains(t, log[0], "DONE")
+	}
+}
@@ -0,0 +1,407 @@
+package ninep
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Message types. Each T-message is answered by the R-message following it, or by rerror.
+const (
+	tversion uint8 = 100 + iota
+	rversion
+	tauth
+	rauth
+	tattach
+	rattach
+	terror // not used
+	rerror
+	tflush
+	rflush
+	twalk
+	rwalk
+	topen
+	ropen
+	tcreate
+	rcreate
+	tread
+	rread
+	twrite
+	rwrite
+	tclunk
+	rclunk
+	tremove
+	rremove
+	tstat
+	rstat
+	twstat
+	rwstat
+)
+
+const (
+	noTag uint16 = 0xFFFF
+	noFid uint32 = 0xFFFFFFFF
+
+	// ioHdrSize is the size of the header of Tread, Rread and Twrite messages, which limits how much
+	// data fits in a message of msize bytes.
+	ioHdrSize = 24
+	// maxWalk is the most names a Twalk may hold.
+	maxWalk = 16
+)
+
+// Qid types.
+const (
+	qtDir    uint8 = 0x80
+	qtAppend uint8 = 0x40
+)
+
+// Open modes.
+const (
+	oRead   uint8 = 0
+	oWrite  uint8 = 1
+	oRdwr   uint8 = 2
+	oExec   uint8 = 3
+	oTrunc  uint8 = 0x10
+	oRclose uint8 = 0x40
+)
+
+// Mode bits of a Dir, besides the permission bits.
+const (
+	// DMDIR is set for directories.
+	DMDIR uint32 = 0x80000000
+	// DMAPPEND is set for append-only files. Writes to them ignore the offset.
+	DMAPPEND uint32 = 0x40000000
+)
+
+var errShort = errors.New("short message")
+
+// qid is the server's unique identification of a file.
+type qid struct {
+	typ  uint8
+	vers uint32
+	path uint64
+}
+
+// fcall is a 9P2000 message. Only the fields used by its type are meaningful.
+type fcall struct {
+	typ     uint8
+	tag     uint16
+	fid     uint32
+	afid    uint32
+	newfid  uint32
+	msize   uint32
+	version string
+	uname   string
+	aname   string
+	ename   string
+	oldtag  uint16
+	wname   []string
+	wqid    []qid
+	qid     qid
+	iounit  uint32
+	mode    uint8
+	perm    uint32
+	name    string
+	offset  uint64
+	count   uint32
+	data    []byte
+	stat    []byte
+}
+
+type encoder []byte
+
+func (e *encoder) p8(v uint8)   { *e = append(*e, v) }
+func (e *encoder) p16(v uint16) { *e = append(*e, byte(v), byte(v>>8)) }
+func (e *encoder) p32(v uint32) { *e = append(*e, byte(v), byte(v>>8), byte(v>>16), byte(v>>24)) }
+func (e *encoder) p64(v uint64) { e.p32(uint32(v)); e.p32(uint32(v >> 32)) }
+func (e *encoder) pstr(s string) {
+	e.p16(uint16(len(s)))
+	*e = append(*e, s...)
+}
+func (e *encoder) pqid(q qid) {
+	e.p8(q.typ)
+	e.p32(q.vers)
+	e.p64(q.path)
+}
+
+// decoder reads the fields of a message. After the first short read, it returns zero values and
+// err is set.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err != nil || len(d.b) < n {
+		d.err = errShort
+		return nil
+	}
+	v := d.b[:n]
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) g8() uint8 {
+	if b := d.take(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) g16() uint16 {
+	if b := d.take(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *decoder) g32() uint32 {
+	if b := d.take(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *decoder) g64() uint64 {
+	if b := d.take(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (d *decoder) gstr() string {
+	return string(d.take(int(d.g16())))
+}
+
+func (d *decoder) gqid() qid {
+	return qid{d.g8(), d.g32(), d.g64()}
+}
+
+// marshal returns f in its wire format, including the size.
+func (f *fcall) marshal() []byte {
+	e := encoder{0, 0, 0, 0}
+	e.p8(f.typ)
+	e.p16(f.tag)
+	switch f.typ {
+	case tversion, rversion:
+		e.p32(f.msize)
+		e.pstr(f.version)
+	case tauth:
+		e.p32(f.afid)
+		e.pstr(f.uname)
+		e.pstr(f.aname)
+	case tattach:
+		e.p32(f.fid)
+		e.p32(f.afid)
+		e.pstr(f.uname)
+		e.pstr(f.aname)
+	case rauth, rattach:
+		e.pqid(f.qid)
+	case rerror:
+		e.pstr(f.ename)
+	case tflush:
+		e.p16(f.oldtag)
+	case twalk:
+		e.p32(f.fid)
+		e.p32(f.newfid)
+		e.p16(uint16(len(f.wname)))
+		for _, name := range f.wname {
+			e.pstr(name)
+		}
+	case rwalk:
+		e.p16(uint16(len(f.wqid)))
+		for _, q := range f.wqid {
+			e.pqid(q)
+		}
+	case topen:
+		e.p32(f.fid)
+		e.p8(f.mode)
+	case tcreate:
+		e.p32(f.fid)
+		e.pstr(f.name)
+		e.p32(f.perm)
+		e.p8(f.mode)
+	case ropen, rcreate:
+		e.pqid(f.qid)
+		e.p32(f.iounit)
+	case tread:
+		e.p32(f.fid)
+		e.p64(f.offset)
+		e.p32(f.count)
+	case rread:
+		e.p32(uint32(len(f.data)))
+		e = append(e, f.data...)
+	case twrite:
+		e.p32(f.fid)
+		e.p64(f.offset)
+		e.p32(uint32(len(f.data)))
+		e = append(e, f.data...)
+	case rwrite:
+		e.p32(f.count)
+	case tclunk, tremove, tstat:
+		e.p32(f.fid)
+	case rstat:
+		e.p16(uint16(len(f.stat)))
+		e = append(e, f.stat...)
+	case twstat:
+		e.p32(f.fid)
+		e.p16(uint16(len(f.stat)))
+		e = append(e, f.stat...)
+	}
+	binary.LittleEndian.PutUint32(e, uint32(len(e)))
+	return e
+}
+
+// unmarshal parses a message from b, which holds everything following the message's size.
+func unmarshal(b []byte) (*fcall, error) {
+	d := &decoder{b: b}
+	f := &fcall{typ: d.g8(), tag: d.g16()}
+	switch f.typ {
+	case tversion, rversion:
+		f.msize = d.g32()
+		f.version = d.gstr()
+	case tauth:
+		f.afid = d.g32()
+		f.uname = d.gstr()
+		f.aname = d.gstr()
+	case tattach:
+		f.fid = d.g32()
+		f.afid = d.g32()
+		f.uname = d.gstr()
+		f.aname = d.gstr()
+	case rauth, rattach:
+		f.qid = d.gqid()
+	case rerror:
+		f.ename = d.gstr()
+	case tflush:
+		f.oldtag = d.g16()
+	case twalk:
+		f.fid = d.g32()
+		f.newfid = d.g32()
+		n := int(d.g16())
+		if n > maxWalk {
+			return nil, fmt.Errorf("too many names in walk: %d", n)
+		}
+		for i := 0; i < n; i++ {
+			f.wname = append(f.wname, d.gstr())
+		}
+	case rwalk:
+		n := int(d.g16())
+		if n > maxWalk {
+			return nil, fmt.Errorf("too many qids in walk: %d", n)
+		}
+		for i := 0; i < n; i++ {
+			f.wqid = append(f.wqid, d.gqid())
+		}
+	case topen:
+		f.fid = d.g32()
+		f.mode = d.g8()
+	case tcreate:
+		f.fid = d.g32()
+		f.name = d.gstr()
+		f.perm = d.g32()
+		f.mode = d.g8()
+	case ropen, rcreate:
+		f.qid = d.gqid()
+		f.iounit = d.g32()
+	case tread:
+		f.fid = d.g32()
+		f.offset = d.g64()
+		f.count = d.g32()
+	case rread:
+		f.data = d.take(int(d.g32()))
+	case twrite:
+		f.fid = d.g32()
+		f.offset = d.g64()
+		f.data = d.take(int(d.g32()))
+	case rwrite:
+		f.count = d.g32()
+	case tclunk, tremove, tstat:
+		f.fid = d.g32()
+	case rstat:
+		f.stat = d.take(int(d.g16()))
+	case twstat:
+		f.fid = d.g32()
+		f.stat = d.take(int(d.g16()))
+	case rflush, rclunk, rremove, rwstat:
+	default:
+		return nil, fmt.Errorf("unknown message type %d", f.typ)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	if len(d.b) != 0 {
+		return nil, fmt.Errorf("%d extra bytes in message type %d", len(d.b), f.typ)
+	}
+	return f, nil
+}
+
+// readMsg reads a message of at most msize bytes from r.
+func readMsg(r io.Reader, msize uint32) (*fcall, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.LittleEndian.Uint32(size[:])
+	if n < 7 || n > msize {
+		return nil, fmt.Errorf("bad message size %d", n)
+	}
+	b := make([]byte, n-4)
+	if _, err := io.ReadFull(r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return unmarshal(b)
+}
+
+// stat is the machine-independent form of a Dir, as sent in Rstat and Rread of a directory. uid is
+// used as the file's user, group and last modifier.
+type stat struct {
+	Dir
+	qid qid
+	uid string
+}
+
+func (s *stat) marshal() []byte {
+	e := encoder{0, 0}
+	e.p16(0) // type
+	e.p32(0) // dev
+	e.pqid(s.qid)
+	e.p32(s.Mode)
+	e.p32(uint32(s.Mtime.Unix())) // atime
+	e.p32(uint32(s.Mtime.Unix()))
+	e.p64(s.Length)
+	e.pstr(s.Name)
+	e.pstr(s.uid)
+	e.pstr(s.uid)
+	e.pstr(s.uid)
+	binary.LittleEndian.PutUint16(e, uint16(len(e)-2))
+	return e
+}
+
+func unmarshalStat(b []byte) (*stat, error) {
+	d := &decoder{b: b}
+	var s stat
+	if n := int(d.g16()); n != len(d.b) {
+		return nil, fmt.Errorf("bad stat size %d", n)
+	}
+	d.g16()
+	d.g32()
+	s.qid = d.gqid()
+	s.Mode = d.g32()
+	d.g32()
+	s.Mtime = time.Unix(int64(d.g32()), 0)
+	s.Length = d.g64()
+	s.Name = d.gstr()
+	s.uid = d.gstr()
+	d.gstr()
+	d.gstr()
+	if d.err != nil {
+		return nil, d.err
+	}
+	return &s, nil
+}
@@ -0,0 +1,459 @@
+// Package ninep serves a synthetic file tree over 9P2000, the Plan 9 file protocol, so that it can
+// be mounted (e.g. with 9pfuse or the Linux v9fs) or scripted with tools like plan9port's 9p.
+package ninep
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// Dir describes a file in an FS.
+type Dir struct {
+	Name string
+	// Mode holds the permission bits of the file, and DMDIR or DMAPPEND.
+	Mode   uint32
+	Length uint64
+	Mtime  time.Time
+}
+
+// FS is a file tree served by Serve. Files are named by their path elements; the root is the empty
+// path.
+//
+// Files are read and written whole. Their contents are read with ReadFile when they are opened, and
+// everything written to a file between opening and closing it is passed to WriteFile when it is
+// closed. For append-only files (see DMAPPEND), WriteFile is only given what was written.
+//
+// An FS may be used by several connections at once.
+type FS interface {
+	Stat(path []string) (Dir, error)
+	ReadDir(path []string) ([]Dir, error)
+	ReadFile(path []string) ([]byte, error)
+	WriteFile(path []string, data []byte) error
+	Remove(path []string) error
+}
+
+// maxMsize is the largest message size the server will agree to.
+const maxMsize = 64*1024 + ioHdrSize
+
+// maxFileSize is the most that can be written to a file between opening and closing it.
+const maxFileSize = 16 << 20
+
+var (
+	errUnknownFid = errors.New("unknown fid")
+	errNotExist   = errors.New("file does not exist")
+	errFidInUse   = errors.New("fid already in use")
+	errNotDir     = errors.New("not a directory")
+	errIsDir      = errors.New("is a directory")
+	errPerm       = errors.New("permission denied")
+	errOpen       = errors.New("file already open")
+	errNotOpen    = errors.New("file not open for that")
+	errDirOffset  = errors.New("bad offset in directory read")
+	errNoAuth     = errors.New("authentication not required")
+	errNoCreate   = errors.New("files cannot be created here")
+	errWstat      = errors.New("only the length and times of a file can be changed")
+	errVersion    = errors.New("version not negotiated")
+	errOffset     = errors.New("write past the end of the file")
+	errTooBig     = errors.New("file too large")
+)
+
+// file is the state of a fid.
+type file struct {
+	path []string
+	dir  Dir
+	// mode is the mode the file was opened with, or -1 if it isn't open.
+	mode int
+	// data holds the contents of a file open for reading, and the Stat entries of an open
+	// directory, read when it was opened.
+	data    []byte
+	entries [][]byte
+	// next and nextOffset are the index and offset of the next entry of a directory to read.
+	next       int
+	nextOffset uint64
+	// buf is what will be written to a file open for writing when it is closed.
+	buf   []byte
+	dirty bool
+}
+
+func (f *file) readable() bool {
+	m := uint8(f.mode) & 3
+	return f.mode >= 0 && (m == oRead || m == oRdwr || m == oExec)
+}
+
+func (f *file) writable() bool {
+	m := uint8(f.mode) & 3
+	return f.mode >= 0 && (m == oWrite || m == oRdwr)
+}
+
+// conn is a connection from a client.
+type conn struct {
+	fs    FS
+	rw    io.ReadWriter
+	msize uint32
+	uname string
+	files map[uint32]*file
+}
+
+// Serve serves fs to each connection accepted from l, until l fails.
+func Serve(l net.Listener, fs FS) error {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer c.Close()
+			ServeConn(c, fs)
+		}()
+	}
+}
+
+// ServeConn serves fs to a single client connected by rw, until the client disconnects. If serving
+// a request panics, ServeConn returns the panic as an error, so that one client can't bring down the
+// others.
+func ServeConn(rw io.ReadWriter, fs FS) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("ninep: panic serving connection: %v", r)
+		}
+	}()
+	c := &conn{fs: fs, rw: rw, msize: maxMsize}
+	for {
+		req, err := readMsg(rw, c.msize)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		resp := c.handle(req)
+		resp.tag = req.tag
+		if _, err := rw.Write(resp.marshal()); err != nil {
+			return err
+		}
+	}
+}
+
+func qidPath(path []string) uint64 {
+	h := fnv.New64a()
+	io.WriteString(h, "/"+strings.Join(path, "/"))
+	return h.Sum64()
+}
+
+func makeQid(path []string, d Dir) qid {
+	return qid{typ: uint8(d.Mode >> 24), path: qidPath(path)}
+}
+
+func (c *conn) stat(path []string, d Dir) []byte {
+	if len(path) == 0 {
+		d.Name = "/"
+	}
+	s := stat{Dir: d, qid: makeQid(path, d), uid: c.uname}
+	return s.marshal()
+}
+
+func (c *conn) handle(req *fcall) *fcall {
+	if c.files == nil && req.typ != tversion {
+		return &fcall{typ: rerror, ename: errVersion.Error()}
+	}
+	var (
+		resp *fcall
+		err  error
+	)
+	switch req.typ {
+	case tversion:
+		resp = c.version(req)
+	case tauth:
+		err = errNoAuth
+	case tattach:
+		resp, err = c.attach(req)
+	case tflush:
+		// Requests are answered in order, so there is never anything left to flush.
+		resp = &fcall{typ: rflush}
+	case twalk:
+		resp, err = c.walk(req)
+	case topen:
+		resp, err = c.open(req)
+	case tcreate:
+		err = errNoCreate
+	case tread:
+		resp, err = c.read(req)
+	case twrite:
+		resp, err = c.write(req)
+	case tclunk:
+		resp, err = c.clunk(req)
+	case tremove:
+		resp, err = c.remove(req)
+	case tstat:
+		resp, err = c.statFid(req)
+	case twstat:
+		resp, err = c.wstat(req)
+	default:
+		err = errors.New("bad message type")
+	}
+	if err != nil {
+		return &fcall{typ: rerror, ename: err.Error()}
+	}
+	return resp
+}
+
+func (c *conn) version(req *fcall) *fcall {
+	c.msize = req.msize
+	if c.msize > maxMsize {
+		c.msize = maxMsize
+	}
+	c.files = make(map[uint32]*file)
+	version := "unknown"
+	if strings.HasPrefix(req.version, "9P2000") {
+		version = "9P2000"
+	}
+	return &fcall{typ: rversion, msize: c.msize, version: version}
+}
+
+func (c *conn) attach(req *fcall) (*fcall, error) {
+	if _, ok := c.files[req.fid]; ok {
+		return nil, errFidInUse
+	}
+	if req.afid != noFid {
+		return nil, errNoAuth
+	}
+	d, err := c.fs.Stat(nil)
+	if err != nil {
+		return nil, err
+	}
+	c.uname = req.uname
+	c.files[req.fid] = &file{dir: d, mode: -1}
+	return &fcall{typ: rattach, qid: makeQid(nil, d)}, nil
+}
+
+func (c *conn) walk(req *fcall) (*fcall, error) {
+	f, ok := c.files[req.fid]
+	if !ok {
+		return nil, errUnknownFid
+	}
+	if f.mode >= 0 {
+		return nil, errOpen
+	}
+	if _, ok := c.files[req.newfid]; ok && req.newfid != req.fid {
+		return nil, errFidInUse
+	}
+	path := append([]string(nil), f.path...)
+	d := f.dir
+	resp := &fcall{typ: rwalk}
+	for i, name := range req.wname {
+		if d.Mode&DMDIR == 0 {
+			if i == 0 {
+				return nil, errNotDir
+			}
+			break
+		}
+		var (
+			next []string
+			nd   Dir
+			err  error
+		)
+		switch {
+		case name == "..":
+			if len(path) > 0 {
+				next = path[:len(path)-1]
+			}
+			nd, err = c.fs.Stat(next)
+		case name == "" || name == "." || strings.Contains(name, "/"):
+			err = errNotExist
+		default:
+			next = append(append([]string(nil), path...), name)
+			nd, err = c.fs.Stat(next)
+		}
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			break
+		}
+		path, d = next, nd
+		resp.wqid = append(resp.wqid, makeQid(path, d))
+	}
+	if len(resp.wqid) == len(req.wname) {
+		c.files[req.newfid] = &file{path: path, dir: d, mode: -1}
+	}
+	return resp, nil
+}
+
+func (c *conn) open(req *fcall) (*fcall, error) {
+	f, ok := c.files[req.fid]
+	if !ok {
+		return nil, errUnknownFid
+	}
+	if f.mode >= 0 {
+		return nil, errOpen
+	}
+	d, err := c.fs.Stat(f.path)
+	if err != nil {
+		return nil, err
+	}
+	f.dir = d
+	o := &file{mode: int(req.mode)}
+	if (o.readable() && d.Mode&0400 == 0) || (o.writable() && d.Mode&0200 == 0) {
+		return nil, errPerm
+	}
+	if d.Mode&DMDIR != 0 {
+		if o.writable() || req.mode&oTrunc != 0 {
+			return nil, errIsDir
+		}
+		dirs, err := c.fs.ReadDir(f.path)
+		if err != nil {
+			return nil, err
+		}
+		f.entries = nil
+		for _, cd := range dirs {
+			f.entries = append(f.entries, c.stat(append(append([]string(nil), f.path...), cd.Name), cd))
+		}
+		f.next, f.nextOffset = 0, 0
+	} else {
+		if o.readable() || (o.writable() && req.mode&oTrunc == 0 && d.Mode&DMAPPEND == 0) {
+			if f.data, err = c.fs.ReadFile(f.path); err != nil {
+				return nil, err
+			}
+		}
+		if o.writable() && req.mode&oTrunc == 0 && d.Mode&DMAPPEND == 0 {
+			f.buf = append([]byte(nil), f.data...)
+		}
+		// Truncating a file is a change even if nothing is written to it.
+		f.dirty = o.writable() && req.mode&oTrunc != 0 && d.Mode&DMAPPEND == 0
+	}
+	f.mode = o.mode
+	return &fcall{typ: ropen, qid: makeQid(f.path, d)}, nil
+}
+
+func (c *conn) read(req *fcall) (*fcall, error) {
+	f, ok := c.files[req.fid]
+	if !ok {
+		return nil, errUnknownFid
+	}
+	if !f.readable() {
+		return nil, errNotOpen
+	}
+	count := req.count
+	if count > c.msize-ioHdrSize {
+		count = c.msize - ioHdrSize
+	}
+	resp := &fcall{typ: rread, data: []byte{}}
+	if f.dir.Mode&DMDIR != 0 {
+		if req.offset == 0 {
+			f.next, f.nextOffset = 0, 0
+		} else if req.offset != f.nextOffset {
+			return nil, errDirOffset
+		}
+		for f.next < len(f.entries) && len(resp.data)+len(f.entries[f.next]) <= int(count) {
+			resp.data = append(resp.data, f.entries[f.next]...)
+			f.next++
+		}
+		f.nextOffset += uint64(len(resp.data))
+		return resp, nil
+	}
+	if req.offset < uint64(len(f.data)) {
+		end := req.offset + uint64(count)
+		if end > uint64(len(f.data)) {
+			end = uint64(len(f.data))
+		}
+		resp.data = f.data[req.offset:end]
+	}
+	return resp, nil
+}
+
+func (c *conn) write(req *fcall) (*fcall, error) {
+	f, ok := c.files[req.fid]
+	if !ok {
+		return nil, errUnknownFid
+	}
+	if !f.writable() {
+		return nil, errNotOpen
+	}
+	offset := req.offset
+	if f.dir.Mode&DMAPPEND != 0 {
+		offset = uint64(len(f.buf))
+	}
+	// Files are written whole, so a write may only start within what has been written, or at its
+	// end. This also keeps a client from making the server allocate whatever the offset asks for.
+	if offset > uint64(len(f.buf)) {
+		return nil, errOffset
+	}
+	if offset+uint64(len(req.data)) > maxFileSize {
+		return nil, errTooBig
+	}
+	if f.dir.Mode&DMAPPEND != 0 {
+		f.buf = append(f.buf, req.data...)
+	} else {
+		end := req.offset + uint64(len(req.data))
+		if end > uint64(len(f.buf)) {
+			f.buf = append(f.buf, make([]byte, end-uint64(len(f.buf)))...)
+		}
+		copy(f.buf[req.offset:], req.data)
+	}
+	f.dirty = true
+	return &fcall{typ: rwrite, count: uint32(len(req.data))}, nil
+}
+
+// clunk forgets a fid, writing what was written to its file, and removing the file if it was
+// opened with ORCLOSE.
+func (c *conn) clunk(req *fcall) (*fcall, error) {
+	f, ok := c.files[req.fid]
+	if !ok {
+		return nil, errUnknownFid
+	}
+	delete(c.files, req.fid)
+	if f.writable() && f.dirty {
+		if err := c.fs.WriteFile(f.path, f.buf); err != nil {
+			return nil, err
+		}
+	}
+	if f.mode >= 0 && uint8(f.mode)&oRclose != 0 {
+		if err := c.fs.Remove(f.path); err != nil {
+			return nil, err
+		}
+	}
+	return &fcall{typ: rclunk}, nil
+}
+
+func (c *conn) remove(req *fcall) (*fcall, error) {
+	f, ok := c.files[req.fid]
+	if !ok {
+		return nil, errUnknownFid
+	}
+	delete(c.files, req.fid)
+	if err := c.fs.Remove(f.path); err != nil {
+		return nil, err
+	}
+	return &fcall{typ: rremove}, nil
+}
+
+func (c *conn) statFid(req *fcall) (*fcall, error) {
+	f, ok := c.files[req.fid]
+	if !ok {
+		return nil, errUnknownFid
+	}
+	d, err := c.fs.Stat(f.path)
+	if err != nil {
+		return nil, err
+	}
+	return &fcall{typ: rstat, stat: c.stat(f.path, d)}, nil
+}
+
+// wstat accepts, and ignores, changes to a file's length and times, which some clients make when
+// truncating files. Anything else is refused.
+func (c *conn) wstat(req *fcall) (*fcall, error) {
+	if _, ok := c.files[req.fid]; !ok {
+		return nil, errUnknownFid
+	}
+	s, err := unmarshalStat(req.stat)
+	if err != nil {
+		return nil, err
+	}
+	if s.Name != "" || s.Mode != ^uint32(0) || s.uid != "" {
+		return nil, errWstat
+	}
+	return &fcall{typ: rwstat}, nil
+}
@@ -0,0 +1,324 @@
+package ninep
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memFS is an FS of files and directories held in memory, keyed by their paths.
+type memFS struct {
+	mu    sync.Mutex
+	modes map[string]uint32
+	data  map[string]string
+}
+
+func newMemFS() *memFS {
+	return &memFS{
+		modes: map[string]uint32{
+			"":      DMDIR | 0755,
+			"hello": 0644,
+			"log":   DMAPPEND | 0644,
+			"ro":    0444,
+			"d":     DMDIR | 0755,
+			"d/f":   0644,
+		},
+		data: map[string]string{"hello": "hello, world\n", "log": "one\n", "ro": "read only\n", "d/f": "f\n"},
+	}
+}
+
+func (fs *memFS) Stat(path []string) (Dir, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	name := strings.Join(path, "/")
+	mode, ok := fs.modes[name]
+	if !ok {
+		return Dir{}, errors.New("file does not exist")
+	}
+	d := Dir{Mode: mode, Length: uint64(len(fs.data[name])), Mtime: time.Unix(1600000000, 0)}
+	if len(path) > 0 {
+		d.Name = path[len(path)-1]
+	}
+	return d, nil
+}
+
+func (fs *memFS) ReadDir(path []string) ([]Dir, error) {
+	var names []string
+	fs.mu.Lock()
+	for name := range fs.modes {
+		p := strings.Split(name, "/")
+		if name != "" && len(p) == len(path)+1 && strings.Join(p[:len(path)], "/") == strings.Join(path, "/") {
+			names = append(names, p[len(p)-1])
+		}
+	}
+	fs.mu.Unlock()
+	sort.Strings(names)
+	var dirs []Dir
+	for _, name := range names {
+		d, _ := fs.Stat(append(path, name))
+		dirs = append(dirs, d)
+	}
+	return dirs, nil
+}
+
+func (fs *memFS) ReadFile(path []string) ([]byte, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return []byte(fs.data[strings.Join(path, "/")]), nil
+}
+
+func (fs *memFS) WriteFile(path []string, data []byte) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	name := strings.Join(path, "/")
+	if fs.modes[name]&DMAPPEND != 0 {
+		fs.data[name] += string(data)
+	} else {
+		fs.data[name] = string(data)
+	}
+	return nil
+}
+
+func (fs *memFS) Remove(path []string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	delete(fs.modes, strings.Join(path, "/"))
+	return nil
+}
+
+type client struct {
+	t   *testing.T
+	c   net.Conn
+	tag uint16
+}
+
+func dial(t *testing.T, fs FS) *client {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go Serve(l, fs)
+	c, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	cl := &client{t: t, c: c}
+	r := cl.rpc(&fcall{typ: tversion, msize: 8192, version: "9P2000"})
+	assert.Equal(t, "9P2000", r.version)
+	assert.Equal(t, uint32(8192), r.msize)
+	r = cl.rpc(&fcall{typ: tattach, fid: 0, afid: noFid, uname: "glenda"})
+	assert.Equal(t, qtDir, r.qid.typ)
+	return cl
+}
+
+func (c *client) rpc(f *fcall) *fcall {
+	c.t.Helper()
+	f.tag = c.tag
+	c.tag++
+	if _, err := c.c.Write(f.marshal()); err != nil {
+		c.t.Fatal(err)
+	}
+	r, err := readMsg(c.c, maxMsize)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	assert.Equal(c.t, f.tag, r.tag)
+	return r
+}
+
+// open walks fid 0 to the named file as fid, and opens it with mode.
+func (c *client) open(fid uint32, name string, mode uint8) *fcall {
+	c.t.Helper()
+	var wname []string
+	if name != "" {
+		wname = strings.Split(name, "/")
+	}
+	r := c.rpc(&fcall{typ: twalk, fid: 0, newfid: fid, wname: wname})
+	if r.typ == rerror {
+		return r
+	}
+	return c.rpc(&fcall{typ: topen, fid: fid, mode: mode})
+}
+
+func (c *client) readAll(fid uint32) []byte {
+	c.t.Helper()
+	var data []byte
+	for {
+		r := c.rpc(&fcall{typ: tread, fid: fid, offset: uint64(len(data)), count: 5})
+		if r.typ != rread {
+			c.t.Fatalf("read: %s", r.ename)
+		}
+		if len(r.data) == 0 {
+			return data
+		}
+		data = append(data, r.data...)
+	}
+}
+
+func TestServeRead(t *testing.T) {
+	c := dial(t, newMemFS())
+
+	r := c.open(1, "hello", oRead)
+	assert.Equal(t, ropen, r.typ)
+	assert.Equal(t, "hello, world\n", string(c.readAll(1)))
+	r = c.rpc(&fcall{typ: tread, fid: 1, offset: 7, count: 100})
+	assert.Equal(t, "world\n", string(r.data))
+	assert.Equal(t, rclunk, c.rpc(&fcall{typ: tclunk, fid: 1}).typ)
+
+	r = c.rpc(&fcall{typ: tstat, fid: 0})
+	s, err := unmarshalStat(r.stat)
+	assert.Nil(t, err)
+	assert.Equal(t, "/", s.Name)
+	assert.Equal(t, "glenda", s.uid)
+
+	// Directory reads return whole entries.
+	r = c.open(1, "", oRead)
+	assert.Equal(t, ropen, r.typ)
+	var names []string
+	var offset uint64
+	for {
+		r = c.rpc(&fcall{typ: tread, fid: 1, offset: offset, count: 100})
+		assert.Equal(t, rread, r.typ)
+		if len(r.data) == 0 {
+			break
+		}
+		offset += uint64(len(r.data))
+		for b := r.data; len(b) > 0; {
+			n := int(b[0]) | int(b[1])<<8 + 2
+			s, err := unmarshalStat(b[:n])
+			assert.Nil(t, err)
+			names = append(names, s.Name)
+			b = b[n:]
+		}
+	}
+	assert.Equal(t, []string{"d", "hello", "log", "ro"}, names)
+	r = c.rpc(&fcall{typ: tread, fid: 1, offset: 3, count: 100})
+	assert.Equal(t, errDirOffset.Error(), r.ename)
+}
+
+func TestServeWalk(t *testing.T) {
+	c := dial(t, newMemFS())
+
+	r := c.rpc(&fcall{typ: twalk, fid: 0, newfid: 1, wname: []string{"d", "f"}})
+	assert.Equal(t, rwalk, r.typ)
+	assert.Len(t, r.wqid, 2)
+	assert.Equal(t, qtDir, r.wqid[0].typ)
+	assert.Equal(t, "f\n", string(c.readAllOpen(1)))
+
+	r = c.rpc(&fcall{typ: twalk, fid: 0, newfid: 2, wname: []string{"d", "..", "hello"}})
+	assert.Len(t, r.wqid, 3)
+
+	// A walk that fails part way returns the qids it got, and doesn't make the new fid.
+	r = c.rpc(&fcall{typ: twalk, fid: 0, newfid: 3, wname: []string{"d", "nope"}})
+	assert.Equal(t, rwalk, r.typ)
+	assert.Len(t, r.wqid, 1)
+	assert.Equal(t, errUnknownFid.Error(), c.rpc(&fcall{typ: tstat, fid: 3}).ename)
+
+	assert.Equal(t, "file does not exist", c.rpc(&fcall{typ: twalk, fid: 0, newfid: 3, wname: []string{"nope"}}).ename)
+	assert.Equal(t, errNotDir.Error(), c.rpc(&fcall{typ: twalk, fid: 2, newfid: 3, wname: []string{"x"}}).ename)
+	assert.Equal(t, errFidInUse.Error(), c.rpc(&fcall{typ: twalk, fid: 0, newfid: 1}).ename)
+}
+
+func (c *client) readAllOpen(fid uint32) []byte {
+	c.t.Helper()
+	c.rpc(&fcall{typ: topen, fid: fid, mode: oRead})
+	return c.readAll(fid)
+}
+
+func TestServeWrite(t *testing.T) {
+	fs := newMemFS()
+	c := dial(t, fs)
+
+	// Writes are applied when the file is closed.
+	c.open(1, "hello", oWrite|oTrunc)
+	r := c.rpc(&fcall{typ: twrite, fid: 1, data: []byte("bye\n")})
+	assert.Equal(t, uint32(4), r.count)
+	assert.Equal(t, "hello, world\n", fs.data["hello"])
+	c.rpc(&fcall{typ: tclunk, fid: 1})
+	assert.Equal(t, "bye\n", fs.data["hello"])
+
+	// Without OTRUNC, writes change the file's contents in place.
+	c.open(1, "hello", oRdwr)
+	c.rpc(&fcall{typ: twrite, fid: 1, offset: 0, data: []byte("B")})
+	c.rpc(&fcall{typ: twrite, fid: 1, offset: 4, data: []byte("!\n")})
+	c.rpc(&fcall{typ: tclunk, fid: 1})
+	assert.Equal(t, "Bye\n!\n", fs.data["hello"])
+
+	// Writes to append-only files ignore the offset, and only what was written is passed on.
+	c.open(1, "log", oWrite)
+	c.rpc(&fcall{typ: twrite, fid: 1, offset: 0, data: []byte("two\n")})
+	c.rpc(&fcall{typ: twrite, fid: 1, offset: 0, data: []byte("three\n")})
+	c.rpc(&fcall{typ: tclunk, fid: 1})
+	assert.Equal(t, "one\ntwo\nthree\n", fs.data["log"])
+
+	// Writes can't leave a gap, or grow a file without limit.
+	c.open(1, "hello", oWrite|oTrunc)
+	assert.Equal(t, errOffset.Error(), c.rpc(&fcall{typ: twrite, fid: 1, offset: 1 << 62, data: []byte("x")}).ename)
+	assert.Equal(t, errOffset.Error(), c.rpc(&fcall{typ: twrite, fid: 1, offset: 1, data: []byte("x")}).ename)
+	big := make([]byte, 8000)
+	for i := 0; i < maxFileSize/len(big); i++ {
+		c.rpc(&fcall{typ: twrite, fid: 1, offset: uint64(i * len(big)), data: big})
+	}
+	assert.Equal(t, errTooBig.Error(), c.rpc(&fcall{typ: twrite, fid: 1, offset: maxFileSize / 8000 * 8000, data: big}).ename)
+	c.rpc(&fcall{typ: tclunk, fid: 1})
+	assert.Len(t, fs.data["hello"], maxFileSize/8000*8000)
+
+	assert.Equal(t, errPerm.Error(), c.open(1, "ro", oWrite).ename)
+	assert.Equal(t, errNotOpen.Error(), c.rpc(&fcall{typ: twrite, fid: 1, data: []byte("x")}).ename)
+	c.rpc(&fcall{typ: tclunk, fid: 1})
+	assert.Equal(t, errIsDir.Error(), c.open(1, "d", oWrite).ename)
+	c.rpc(&fcall{typ: tclunk, fid: 1})
+	assert.Equal(t, errNoCreate.Error(), c.rpc(&fcall{typ: tcreate, fid: 0, name: "new", perm: 0644, mode: oWrite}).ename)
+
+	c.rpc(&fcall{typ: twalk, fid: 0, newfid: 1, wname: []string{"d", "f"}})
+	assert.Equal(t, rremove, c.rpc(&fcall{typ: tremove, fid: 1}).typ)
+	_, ok := fs.modes["d/f"]
+	assert.False(t, ok)
+}
+
+func TestServeVersion(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go Serve(l, newMemFS())
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	c := &client{t: t, c: conn}
+
+	assert.Equal(t, errVersion.Error(), c.rpc(&fcall{typ: tattach, afid: noFid}).ename)
+	r := c.rpc(&fcall{typ: tversion, msize: 1 << 20, version: "9P2000.L"})
+	assert.Equal(t, "9P2000", r.version)
+	assert.Equal(t, uint32(maxMsize), r.msize)
+	r = c.rpc(&fcall{typ: tversion, msize: 8192, version: "9P1"})
+	assert.Equal(t, "unknown", r.version)
+}
+
+// panicFS panics on every Stat.
+type panicFS struct{ *memFS }
+
+func (panicFS) Stat(path []string) (Dir, error) { panic("boom") }
+
+func TestServeConnPanic(t *testing.T) {
+	sc, cc := net.Pipe()
+	defer cc.Close()
+	done := make(chan error)
+	go func() { done <- ServeConn(sc, panicFS{newMemFS()}) }()
+	c := &client{t: t, c: cc}
+	c.rpc(&fcall{typ: tversion, msize: 8192, version: "9P2000"})
+	cc.Write((&fcall{typ: tattach, afid: noFid}).marshal())
+	err := <-done
+	assert.EqualError(t, err, "ninep: panic serving connection: boom")
+}
@@ -0,0 +1,80 @@
+package today
+
+import (
+	"regexp"
+	"strings"
+)
+
+// noteKeyRe matches keyed notes. A key is a word made of letters, digits, '_', '.' and '-',
+// followed by a colon and whitespace, so that URLs and times are not mistaken for keys.
+var noteKeyRe = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9_.-]*):[[:space:]]+(.*)$`)
+
+// splitNote splits a keyed note into its key and value. ok is false if line is not a keyed note.
+func splitNote(line string) (key, value string, ok bool) {
+	m := noteKeyRe.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], strings.TrimSpace(m[2]), true
+}
+
+// Get returns the value of the keyed note with the given key. Notes may be keyed by starting them
+// with a key and a colon:
+//   k8s-ctx: kubectl config use-context prod
+// Lines that don't look like that are ordinary notes and are left alone by Get, Set, Remove and
+// Keys.
+func (l Lines) Get(key string) (string, bool) {
+	for _, line := range l {
+		if k, v, ok := splitNote(line); ok && k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Set sets the value of the keyed note with the given key, replacing the first note with that key
+// if there is one, and otherwise adding a new note after the last non-blank line.
+func (l *Lines) Set(key, value string) {
+	note := key + ": " + value
+	last := -1
+	for i, line := range *l {
+		if k, _, ok := splitNote(line); ok && k == key {
+			(*l)[i] = note
+			return
+		}
+		if strings.TrimSpace(line) != "" {
+			last = i
+		}
+	}
+	*l = append(*l, "")
+	copy((*l)[last+2:], (*l)[last+1:])
+	(*l)[last+1] = note
+}
+
+// Remove removes every keyed note with the given key. It returns false if there were none.
+func (l *Lines) Remove(key string) bool {
+	k := 0
+	for _, line := range *l {
+		if lk, _, ok := splitNote(line); ok && lk == key {
+			continue
+		}
+		(*l)[k] = line
+		k++
+	}
+	removed := k < len(*l)
+	*l = (*l)[:k]
+	return removed
+}
+
+// Keys returns the keys of the keyed notes in l, in order.
+func (l Lines) Keys() []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, line := range l {
+		if k, _, ok := splitNote(line); ok && !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,46 @@
+package today
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyedNotes(t *testing.T) {
+	notes := Lines{
+		"foop boop doop This is a note.",
+		"k8s-ctx: kubectl config use-context prod",
+		"see http://example.com/docs",
+		"8:30 is standup",
+		"deploy: make deploy",
+		"",
+	}
+
+	v, ok := notes.Get("k8s-ctx")
+	assert.True(t, ok)
+	assert.Equal(t, "kubectl config use-context prod", v)
+	_, ok = notes.Get("http")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"k8s-ctx", "deploy"}, notes.Keys())
+
+	notes.Set("deploy", "make deploy ENV=prod")
+	notes.Set("build", "make build")
+	assert.Equal(t, Lines{
+		"foop boop doop This is a note.",
+		"k8s-ctx: kubectl config use-context prod",
+		"see http://example.com/docs",
+		"8:30 is standup",
+		"deploy: make deploy ENV=prod",
+		"build: make build",
+		"",
+	}, notes)
+
+	assert.True(t, notes.Remove("k8s-ctx"))
+	assert.False(t, notes.Remove("k8s-ctx"))
+	assert.Equal(t, []string{"deploy", "build"}, notes.Keys())
+	assert.Len(t, notes, 6)
+
+	var empty Lines
+	empty.Set("a", "b")
+	assert.Equal(t, Lines{"a: b"}, empty)
+}
@@ -0,0 +1,237 @@
+package today
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// orgTasksHeading is the heading tasks are written under. The section can't be called "TODO" like
+// it is in a today file, since org-mode would read that as a TODO keyword.
+const orgTasksHeading = "Tasks"
+
+// orgKeywords are the TODO keywords declared in every org file written by WriteOrg, besides "DONE".
+var orgKeywords = []string{"TODO", "IN_PROGRESS", "READY", "REVIEW", "WAITING", "RESPONDED", "STALE", "HOLD"}
+
+// orgKeyword returns the org TODO keyword for a status name. New tasks are "TODO", and spaces are
+// replaced with underscores.
+func orgKeyword(name string) string {
+	if name == "" || name == "?" {
+		return "TODO"
+	}
+	return strings.ReplaceAll(name, " ", "_")
+}
+
+// orgStatus is the inverse of orgKeyword.
+func orgStatus(keyword string) string {
+	if keyword == "TODO" {
+		return "?"
+	}
+	return strings.ReplaceAll(keyword, "_", " ")
+}
+
+func orgTimestamp(date time.Time, active bool) string {
+	if active {
+		return date.Format("<2006-01-02 Mon>")
+	}
+	return date.Format("[2006-01-02 Mon]")
+}
+
+// WriteOrg writes a Today out to writer w as an org-mode document. Startup items become a
+// checklist, Notes are plain text, and Log entries are kept in a LOGBOOK drawer. Each task is a
+// headline under "Tasks" with a TODO keyword for its status:
+//   ** IN_PROGRESS JIRA-12 Fix the frobnicator
+//   - State "IN_PROGRESS" [2020-01-05 Sun]
+//   :PROPERTIES:
+//   :COMMENT:  pr #3
+//   :END:
+//     * step 1
+// The status date is kept in a state change note like the ones org-mode logs, the status comment
+// is kept in the COMMENT property, and the task's comments make up the body. Comments are indented
+// so that ones starting with '*' aren't read as headlines. "HOLD" tasks are SCHEDULED for the day
+// they are held until.
+// (See ParseOrgTasks)
+func (t *Today) WriteOrg(w io.Writer) error {
+	wtr := bufio.NewWriter(w)
+
+	keywords := append([]string(nil), orgKeywords...)
+	declared := make(map[string]bool)
+	for _, k := range keywords {
+		declared[k] = true
+	}
+	for _, task := range t.Tasks.Tasks {
+		if k := orgKeyword(task.Status.Name); k != "DONE" && !declared[k] {
+			declared[k] = true
+			keywords = append(keywords, k)
+		}
+	}
+	wtr.WriteString("#+TODO: " + strings.Join(keywords, " ") + " | DONE\n")
+
+	wtr.WriteString("* " + strings.TrimSuffix(startupLine, ":") + "\n")
+	for _, item := range t.Startup {
+		if item.Status.Name == "DONE" {
+			wtr.WriteString("- [X] ")
+		} else {
+			wtr.WriteString("- [ ] ")
+		}
+		wtr.WriteString(item.Description + "\n")
+	}
+
+	wtr.WriteString("* " + strings.TrimSuffix(notesLine, ":") + "\n")
+	for _, n := range t.Notes {
+		if strings.TrimSpace(n) != "" {
+			wtr.WriteString(strings.TrimSpace(n) + "\n")
+		}
+	}
+
+	wtr.WriteString("* " + strings.TrimSuffix(logLine, ":") + "\n")
+	if len(t.Log) > 0 {
+		wtr.WriteString(":LOGBOOK:\n")
+		for _, l := range t.Log {
+			wtr.WriteString("- " + l + "\n")
+		}
+		wtr.WriteString(":END:\n")
+	}
+
+	wtr.WriteString("* " + orgTasksHeading + "\n")
+	for _, task := range t.Tasks.Tasks {
+		wtr.WriteString("** " + orgKeyword(task.Status.Name))
+		if task.Name != "" {
+			wtr.WriteString(" " + task.Name)
+		}
+		wtr.WriteString(" " + task.Description + "\n")
+		if !task.Status.Date.IsZero() {
+			if task.Status.Name == "HOLD" {
+				wtr.WriteString("SCHEDULED: " + orgTimestamp(task.Status.Date, true) + "\n")
+			} else {
+				wtr.WriteString("- State \"" + orgKeyword(task.Status.Name) + "\" " + orgTimestamp(task.Status.Date, false) + "\n")
+			}
+		}
+		if task.Status.Comment != "" {
+			wtr.WriteString(":PROPERTIES:\n:COMMENT:  " + task.Status.Comment + "\n:END:\n")
+		}
+		for _, c := range task.Comments {
+			wtr.WriteString("  " + c + "\n")
+		}
+	}
+	return wtr.Flush()
+}
+
+var (
+	orgTodoRe      = regexp.MustCompile(`^#\+(?:TODO|SEQ_TODO|TYP_TODO):(.*)$`)
+	orgHeadlineRe  = regexp.MustCompile(`^(\*+)[[:space:]]+(.*?)[[:space:]]*$`)
+	orgScheduledRe = regexp.MustCompile(`^SCHEDULED:[[:space:]]*<([0-9]{4}-[0-9]{2}-[0-9]{2})[^>]*>$`)
+	orgStateRe     = regexp.MustCompile(`^- State "([^"]+)".*\[([0-9]{4}-[0-9]{2}-[0-9]{2})[^\]]*\]$`)
+	orgPropertyRe  = regexp.MustCompile(`^:([A-Za-z0-9_-]+):[[:space:]]*(.*)$`)
+	orgNameRe      = regexp.MustCompile(`^[A-Z]+-[0-9]+$`)
+)
+
+// ParseOrgTasks parses the tasks out of an org-mode document, such as one written by
+// Today.WriteOrg and then edited. Tasks are the headlines under the "Tasks" heading, or, if there
+// isn't one, the top-level headlines that start with a TODO keyword. The keyword becomes the status,
+// so changing a keyword in org-mode and merging the tasks back with TaskList.Merge changes the
+// task's status. The status date is read from the newest state change note for the task's keyword,
+// or for a "HOLD", from its SCHEDULED timestamp, so a date isn't carried over from a keyword the
+// task no longer has.
+func ParseOrgTasks(r io.Reader) ([]*Task, error) {
+	keywords := map[string]bool{"DONE": true}
+	for _, k := range orgKeywords {
+		keywords[k] = true
+	}
+
+	var (
+		tasks      []*Task // headlines under the Tasks heading
+		topLevel   []*Task // top-level headlines with keywords
+		haveTasks  bool
+		inTasks    bool
+		task       *Task
+		inDrawer   bool
+		properties bool
+	)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if m := orgTodoRe.FindStringSubmatch(line); m != nil {
+			for _, k := range strings.Fields(m[1]) {
+				// Keywords may have a fast access key, as in "WAIT(w)".
+				if i := strings.Index(k, "("); i > 0 {
+					k = k[:i]
+				}
+				if k != "|" {
+					keywords[k] = true
+				}
+			}
+			continue
+		}
+		if m := orgHeadlineRe.FindStringSubmatch(scanner.Text()); m != nil {
+			level, title := len(m[1]), m[2]
+			task, inDrawer = nil, false
+			if level == 1 {
+				inTasks = title == orgTasksHeading
+				haveTasks = haveTasks || inTasks
+			}
+			words := strings.Fields(title)
+			if len(words) == 0 {
+				continue
+			}
+			keyword := ""
+			if keywords[words[0]] {
+				keyword, words = words[0], words[1:]
+			}
+			if (!inTasks || level != 2) && (level != 1 || keyword == "") {
+				continue
+			}
+			task = &Task{Status: Status{Name: "?"}}
+			if keyword != "" {
+				task.Status.Name = orgStatus(keyword)
+			}
+			if len(words) > 0 && orgNameRe.MatchString(words[0]) {
+				task.Name, words = words[0], words[1:]
+			}
+			task.Description = strings.Join(words, " ")
+			if level == 1 {
+				topLevel = append(topLevel, task)
+			} else {
+				tasks = append(tasks, task)
+			}
+			continue
+		}
+		if task == nil || line == "" {
+			continue
+		}
+		if inDrawer {
+			if line == ":END:" {
+				inDrawer = false
+			} else if m := orgPropertyRe.FindStringSubmatch(line); m != nil && properties && m[1] == "COMMENT" {
+				task.Status.Comment = m[2]
+			}
+			continue
+		}
+		if line == ":PROPERTIES:" || line == ":LOGBOOK:" {
+			inDrawer, properties = true, line == ":PROPERTIES:"
+			continue
+		}
+		if m := orgStateRe.FindStringSubmatch(line); m != nil {
+			if orgStatus(m[1]) == task.Status.Name && task.Status.Name != "HOLD" && task.Status.Date.IsZero() {
+				task.Status.Date, _ = time.ParseInLocation("2006-01-02", m[2], time.Local)
+			}
+			continue
+		}
+		if m := orgScheduledRe.FindStringSubmatch(line); m != nil {
+			if task.Status.Name == "HOLD" {
+				task.Status.Date, _ = time.ParseInLocation("2006-01-02", m[1], time.Local)
+			}
+			continue
+		}
+		task.Comments = append(task.Comments, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if haveTasks {
+		return tasks, nil
+	}
+	return topLevel, nil
+}
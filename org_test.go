@@ -0,0 +1,100 @@
+package today
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteOrg(t *testing.T) {
+	d := time.Date(2020, 1, 5, 0, 0, 0, 0, time.Local)
+	today := &Today{
+		Startup: List{
+			{Description: "Check email", Status: Status{Name: "DONE", Date: d}},
+			{Description: "Plan the week"},
+		},
+		Notes: Lines{"deploy: make deploy"},
+		Log:   Lines{"8:30AM Started"},
+		Tasks: TaskList{Tasks: []*Task{
+			{Name: "JIRA-12", Description: "Fix the thing", Status: Status{Name: "IN PROGRESS", Comment: "pr #3", Date: d}, Comments: []string{"* step 1"}},
+			{Name: "TASK-4", Description: "Later", Status: Status{Name: "HOLD", Date: d.AddDate(0, 0, 7)}},
+			{Name: "TASK-5", Description: "Odd", Status: Status{Name: "BLOCKED", Date: d}},
+			{Name: "TASK-6", Description: "Write docs", Status: Status{Name: "DONE", Date: d}},
+		}},
+	}
+	var b bytes.Buffer
+	assert.NoError(t, today.WriteOrg(&b))
+	assert.Equal(t, `#+TODO: TODO IN_PROGRESS READY REVIEW WAITING RESPONDED STALE HOLD BLOCKED | DONE
+* Morning Start Up
+- [X] Check email
+- [ ] Plan the week
+* Notes
+deploy: make deploy
+* Log
+:LOGBOOK:
+- 8:30AM Started
+:END:
+* Tasks
+** IN_PROGRESS JIRA-12 Fix the thing
+- State "IN_PROGRESS" [2020-01-05 Sun]
+:PROPERTIES:
+:COMMENT:  pr #3
+:END:
+  * step 1
+** HOLD TASK-4 Later
+SCHEDULED: <2020-01-12 Sun>
+** BLOCKED TASK-5 Odd
+- State "BLOCKED" [2020-01-05 Sun]
+** DONE TASK-6 Write docs
+- State "DONE" [2020-01-05 Sun]
+`, b.String())
+
+	tasks, err := ParseOrgTasks(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, today.Tasks.Tasks, tasks)
+}
+
+func TestParseOrgTasks(t *testing.T) {
+	tasks, err := ParseOrgTasks(strings.NewReader(`#+TODO: TODO WAIT(w) | DONE(d)
+* TODO Fix the sink
+  Call the plumber
+* WAIT JIRA-3 Hear back
+* A heading
+** TODO not top level
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, []*Task{
+		{Description: "Fix the sink", Status: Status{Name: "?"}, Comments: []string{"Call the plumber"}},
+		{Name: "JIRA-3", Description: "Hear back", Status: Status{Name: "WAIT"}},
+	}, tasks)
+}
+
+func TestOrgStatusChange(t *testing.T) {
+	d := time.Date(2020, 1, 5, 0, 0, 0, 0, time.Local)
+	today := &Today{Tasks: TaskList{Tasks: []*Task{
+		{Name: "JIRA-12", Description: "Fix the thing", Status: Status{Name: "IN PROGRESS", Date: d}},
+		{Name: "TASK-4", Description: "Later", Status: Status{Name: "READY", Date: d}},
+	}}}
+	var b bytes.Buffer
+	assert.NoError(t, today.WriteOrg(&b))
+	edited := strings.NewReplacer("IN_PROGRESS JIRA-12", "DONE JIRA-12", "READY TASK-4", "HOLD TASK-4").Replace(b.String())
+
+	tasks, err := ParseOrgTasks(strings.NewReader(edited))
+	assert.NoError(t, err)
+	// The note for the old keyword doesn't date the new one.
+	assert.Equal(t, Status{Name: "DONE"}, tasks[0].Status)
+	assert.Equal(t, Status{Name: "HOLD"}, tasks[1].Status)
+
+	today.Tasks.Merge(tasks)
+	today.Tasks.Update(&today.Log)
+	assert.True(t, sameDay(time.Now(), today.Tasks.Tasks[0].Status.Date))
+	if assert.Len(t, today.Log, 2) {
+		assert.Contains(t, today.Log[0], "Moved JIRA-12")
+		assert.Contains(t, today.Log[0], "DONE")
+		assert.Contains(t, today.Log[1], "Moved TASK-4")
+		assert.Contains(t, today.Log[1], "HOLD")
+	}
+}
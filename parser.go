@@ -24,14 +24,23 @@ const (
 	todoLine    = "TODO:"
 )
 
+// sectionHeaders are the headers of the sections of a today file, in order.
+var sectionHeaders = []string{startupLine, notesLine, logLine, todoLine}
+
+var (
+	// taskRe matches a line of the Tasks section: an optional task name and hyphen, the
+	// description, and an optional status in square brackets.
+	taskRe = regexp.MustCompile(`^(([A-Z]+-[0-9]+)[[:space:]]+-)?(.*?)(\[([^][]*)\])?$`)
+	// listItemRe matches a line of a List: an optional number and period, the description, and an
+	// optional status in square brackets.
+	listItemRe = regexp.MustCompile(`^(([0-9]+)\.)?[[:space:]]*(.*?)(\[([^][]*)\])?$`)
+)
+
 func (p *parser) peekLine() (string, error) {
 	if p.peekp {
 		return p.peek, nil
 	}
-	peek, err := p.rdr.ReadString('\n')
-	if strings.HasSuffix(peek, "\n") {
-		peek = peek[:len(peek)-1]
-	}
+	peek, err := p.readLine()
 	p.peek = peek
 	p.peekp = true
 	return p.peek, err
@@ -44,9 +53,17 @@ func (p *parser) nextLine() (string, error) {
 		p.peekp = false
 		return r, nil
 	}
+	return p.readLine()
+}
+
+// readLine reads the next line, without its newline. A last line with no newline is returned
+// without an error, and io.EOF is returned by the following call.
+func (p *parser) readLine() (string, error) {
 	str, err := p.rdr.ReadString('\n')
 	if strings.HasSuffix(str, "\n") {
 		str = str[0 : len(str)-1]
+	} else if err == io.EOF && str != "" {
+		err = nil
 	}
 	return str, err
 }
@@ -64,6 +81,36 @@ func matchLine(line, match string) bool {
 	return ret
 }
 
+// nextSection returns the index in sectionHeaders of the section line starts, if it is the header
+// of the section after section, and section otherwise.
+func nextSection(section int, line string) int {
+	if section+1 < len(sectionHeaders) && matchLine(line, sectionHeaders[section+1]) {
+		return section + 1
+	}
+	return section
+}
+
+// Section returns the header of the section that lines[n] is in, such as "TODO:", finding the
+// sections the same way Parse does. It returns "" if lines[n] is itself a header, comes before the
+// first section, or doesn't exist.
+func Section(lines []string, n int) string {
+	if n < 0 || n >= len(lines) {
+		return ""
+	}
+	section := -1
+	for i := 0; i <= n; i++ {
+		next := nextSection(section, lines[i])
+		if i == n && next != section {
+			return ""
+		}
+		section = next
+	}
+	if section < 0 {
+		return ""
+	}
+	return sectionHeaders[section]
+}
+
 func parseStatus(s string) Status {
 	re := regexp.MustCompile(`(([A-Z-? ]*?)([[:space:]]+-[[:space:]]+|$))?(.*?)([[:space:]]+-[[:space:]]+(.*?))?$`)
 	matches := re.FindStringSubmatch(s)
@@ -112,8 +159,7 @@ func (p *parser) parseTodo() *Task {
 		return nil
 	}
 
-	re := regexp.MustCompile(`^(([A-Z]+-[0-9]+)[[:space:]]+-)?(.*?)(\[([^][]*)\])?$`)
-	matches := re.FindStringSubmatch(l)
+	matches := taskRe.FindStringSubmatch(l)
 	t.Name = strings.TrimSpace(matches[2])
 	t.Description = strings.TrimSpace(matches[3])
 	t.Status = parseStatus(strings.TrimSpace(matches[5]))
@@ -141,8 +187,7 @@ func (p *parser) parseListItem() *ListItem {
 	if l == "" {
 		return nil
 	}
-	re := regexp.MustCompile(`^(([0-9]+)\.)?[[:space:]]*(.*?)(\[([^][]*)\])?$`)
-	matches := re.FindStringSubmatch(l)
+	matches := listItemRe.FindStringSubmatch(l)
 
 	var itemNumber int
 	if matches[2] != "" {
@@ -151,10 +196,10 @@ func (p *parser) parseListItem() *ListItem {
 			log.Printf("Failed to parse list item number: %s", err)
 		}
 	}
-	comment := strings.TrimSpace(matches[3])
+	comment, sched := splitSchedule(strings.TrimSpace(matches[3]))
 	status := parseStatus(strings.TrimSpace(matches[5]))
 
-	return &ListItem{number: itemNumber, Description: comment, Status: status}
+	return &ListItem{number: itemNumber, Description: comment, Schedule: sched, Status: status}
 }
 
 func (p *parser) parseList(nextSection string) []*ListItem {
@@ -240,7 +285,12 @@ func (p *parser) parseNotes() ([]string, error) {
 			return nil, err
 		}
 		if matchLine(l, notesLine) {
-			return p.parseLinesKeepWhitespace(logLine), nil
+			// Blank lines are kept, except those before the Log, which separate the sections.
+			notes := p.parseLinesKeepWhitespace(logLine)
+			for len(notes) > 0 && strings.TrimSpace(notes[len(notes)-1]) == "" {
+				notes = notes[:len(notes)-1]
+			}
+			return notes, nil
 		}
 	}
 }
@@ -315,3 +365,14 @@ func (p *parser) parse() (*Today, error) {
 func Parse(r io.Reader) (*Today, error) {
 	return newParser(r).parse()
 }
+
+// ParseList parses a List on its own, in the same form as the Startup section of a today file.
+func ParseList(r io.Reader) List {
+	return newParser(r).parseList(notesLine)
+}
+
+// ParseStatus parses a Status from the text that appears between its square brackets in a today
+// file, e.g. "IN PROGRESS - Working on pr #12 - Jan 16, 2020".
+func ParseStatus(s string) Status {
+	return parseStatus(strings.TrimSpace(s))
+}
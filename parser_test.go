@@ -229,3 +229,43 @@ more lines
 		lines,
 	)
 }
+
+func TestParseNoFinalNewline(t *testing.T) {
+	today, err := Parse(strings.NewReader("Morning Start Up:\nNotes:\nLog:\nTODO:\nJIRA-12 - Write it up [READY]"))
+	assert.NoError(t, err)
+	if !assert.NotNil(t, today) || !assert.Len(t, today.Tasks.Tasks, 1) {
+		return
+	}
+	assert.Equal(t, "JIRA-12", today.Tasks.Tasks[0].Name)
+	assert.Equal(t, "READY", today.Tasks.Tasks[0].Status.Name)
+
+	// The last comment of the last task, too.
+	today, err = Parse(strings.NewReader("Morning Start Up:\nNotes:\nLog:\nTODO:\nJIRA-12 - Write it up\n\tsome comment"))
+	assert.NoError(t, err)
+	if assert.NotNil(t, today) && assert.Len(t, today.Tasks.Tasks, 1) {
+		assert.Equal(t, []string{"some comment"}, today.Tasks.Tasks[0].Comments)
+	}
+}
+
+func TestSection(t *testing.T) {
+	lines := strings.Split("preamble\nMorning Start Up:\n1. Check the calendar\n  Notes:  \nTODO: later\nLog:\nTODO:\nJIRA-12 - Write it up [READY]", "\n")
+	var sections []string
+	for n := -1; n <= len(lines); n++ {
+		sections = append(sections, Section(lines, n))
+	}
+	assert.Equal(t,
+		[]string{
+			"",                  // -1
+			"",                  // preamble
+			"",                  // Morning Start Up:
+			"Morning Start Up:", // 1. Check the calendar
+			"",                  //   Notes:
+			"Notes:",            // TODO: later, which isn't the header after Notes.
+			"",                  // Log:
+			"",                  // TODO:
+			"TODO:",             // JIRA-12 - Write it up [READY]
+			"",                  // past the end
+		},
+		sections,
+	)
+}
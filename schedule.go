@@ -0,0 +1,201 @@
+package today
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A schedule is the parsed form of a ListItem's Schedule.
+type schedule struct {
+	weekdays map[time.Weekday]bool
+	days     map[int]bool
+	last     bool
+	dates    map[string]bool
+	tags     []string
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+var ordinalRe = regexp.MustCompile(`^([0-9]{1,2})(st|nd|rd|th)$`)
+
+// scheduleDate is the format of schedule terms naming a single date.
+const scheduleDate = "2006-01-02"
+
+// parseSchedule parses a schedule qualifier, the text between the parentheses. It returns false if
+// any of the comma-separated terms is not a valid schedule term, in which case the parentheses are
+// just part of the item's description.
+func parseSchedule(s string) (*schedule, bool) {
+	sched := &schedule{weekdays: make(map[time.Weekday]bool), days: make(map[int]bool), dates: make(map[string]bool)}
+	terms := strings.Split(s, ",")
+	for _, term := range terms {
+		term = strings.ToLower(strings.TrimSpace(term))
+		if wd, ok := weekdayNames[term]; ok {
+			sched.weekdays[wd] = true
+			continue
+		}
+		if m := ordinalRe.FindStringSubmatch(term); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			if n < 1 || n > 31 {
+				return nil, false
+			}
+			sched.days[n] = true
+			continue
+		}
+		if _, err := time.Parse(scheduleDate, term); err == nil {
+			sched.dates[term] = true
+			continue
+		}
+		switch {
+		case term == "weekdays":
+			for wd := time.Monday; wd <= time.Friday; wd++ {
+				sched.weekdays[wd] = true
+			}
+		case term == "weekends":
+			sched.weekdays[time.Saturday] = true
+			sched.weekdays[time.Sunday] = true
+		case term == "last":
+			sched.last = true
+		case len(term) > 1 && term[0] == '+' && !strings.ContainsAny(term, " \t()"):
+			sched.tags = append(sched.tags, term[1:])
+		default:
+			return nil, false
+		}
+	}
+	return sched, true
+}
+
+func (s *schedule) hasDates() bool {
+	return len(s.weekdays) > 0 || len(s.days) > 0 || s.last || len(s.dates) > 0
+}
+
+// expired reports whether a schedule only names single dates, and none of them are after date.
+func (s *schedule) expired(date time.Time) bool {
+	if len(s.dates) == 0 || len(s.weekdays) > 0 || len(s.days) > 0 || s.last {
+		return false
+	}
+	for d := range s.dates {
+		if d > date.Format(scheduleDate) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *schedule) appliesOn(date time.Time, tags []string) bool {
+	for _, want := range s.tags {
+		found := false
+		for _, tag := range tags {
+			if strings.EqualFold(tag, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !s.hasDates() {
+		return true
+	}
+	if s.weekdays[date.Weekday()] || s.days[date.Day()] || s.dates[date.Format(scheduleDate)] {
+		return true
+	}
+	return s.last && date.AddDate(0, 0, 1).Month() != date.Month()
+}
+
+// splitSchedule separates a trailing schedule qualifier from a Startup item's description.
+func splitSchedule(desc string) (string, string) {
+	if !strings.HasSuffix(desc, ")") {
+		return desc, ""
+	}
+	open := strings.LastIndex(desc, "(")
+	if open < 0 {
+		return desc, ""
+	}
+	qualifier := desc[open+1 : len(desc)-1]
+	if _, ok := parseSchedule(qualifier); !ok {
+		return desc, ""
+	}
+	return strings.TrimSpace(desc[:open]), qualifier
+}
+
+// AppliesOn reports whether the item should be part of the Startup section on date. An item with
+// no Schedule applies every day. tags are the conditions currently in effect, such as "oncall".
+// (See ListItem)
+func (i *ListItem) AppliesOn(date time.Time, tags ...string) bool {
+	if i.Schedule == "" {
+		return true
+	}
+	sched, ok := parseSchedule(i.Schedule)
+	if !ok {
+		return true
+	}
+	return sched.appliesOn(date, tags)
+}
+
+func (i *ListItem) expired(date time.Time) bool {
+	sched, ok := parseSchedule(i.Schedule)
+	return i.Schedule != "" && ok && sched.expired(date)
+}
+
+// For returns copies of the items in l that apply on date, without their statuses. (See
+// ListItem.AppliesOn)
+func (l List) For(date time.Time, tags ...string) List {
+	var day List
+	for _, item := range l {
+		if item.AppliesOn(date, tags...) {
+			day = append(day, &ListItem{Description: item.Description, Schedule: item.Schedule})
+		}
+	}
+	day.Update()
+	return day
+}
+
+// Scheduled reports whether any item in l has a Schedule.
+func (l List) Scheduled() bool {
+	for _, item := range l {
+		if item.Schedule != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Merge returns the full Startup definition l, updated with the edits made to day, the Startup
+// section of a today file for date. Items in day that are not in l were added by hand and are
+// appended to the definition. Items in l that applied on date but are missing from day were removed
+// by hand and are dropped from the definition. Since the tags that were in effect on date are not
+// known, items with tags are never dropped. Items scheduled only for single dates are dropped once
+// the last of them is past. Items are matched by Description. Statuses are not kept.
+func (l List) Merge(day List, date time.Time) List {
+	inDay := make(map[string]bool)
+	for _, item := range day {
+		inDay[item.Description] = true
+	}
+	var merged List
+	inDef := make(map[string]bool)
+	for _, item := range l {
+		inDef[item.Description] = true
+		if !inDay[item.Description] && item.AppliesOn(date) || item.expired(date) {
+			continue
+		}
+		merged = append(merged, &ListItem{Description: item.Description, Schedule: item.Schedule})
+	}
+	for _, item := range day {
+		if !inDef[item.Description] && !item.expired(date) {
+			merged = append(merged, &ListItem{Description: item.Description, Schedule: item.Schedule})
+		}
+	}
+	merged.Update()
+	return merged
+}
@@ -0,0 +1,132 @@
+package today
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitSchedule(t *testing.T) {
+	for _, tc := range []struct {
+		in, desc, sched string
+	}{
+		{"Plan the sprint (Mon)", "Plan the sprint", "Mon"},
+		{"Pay the bills (1st, 15th)", "Pay the bills", "1st, 15th"},
+		{"Check the pager queue (+oncall)", "Check the pager queue", "+oncall"},
+		{"Stretch (weekdays)", "Stretch", "weekdays"},
+		{"Check email (work)", "Check email (work)", ""},
+		{"Check email", "Check email", ""},
+		{"Bad day (32nd)", "Bad day (32nd)", ""},
+	} {
+		desc, sched := splitSchedule(tc.in)
+		assert.Equal(t, tc.desc, desc, tc.in)
+		assert.Equal(t, tc.sched, sched, tc.in)
+	}
+}
+
+func TestAppliesOn(t *testing.T) {
+	// Jan 31, 2020 is a Friday.
+	friday := time.Date(2020, 1, 31, 0, 0, 0, 0, time.Local)
+	monday := time.Date(2020, 2, 3, 0, 0, 0, 0, time.Local)
+	first := time.Date(2020, 2, 1, 0, 0, 0, 0, time.Local)
+
+	item := func(s string) *ListItem { return &ListItem{Description: "item", Schedule: s} }
+	assert.True(t, item("").AppliesOn(friday))
+	assert.True(t, item("Fri").AppliesOn(friday))
+	assert.False(t, item("Fri").AppliesOn(monday))
+	assert.True(t, item("Mon, Fri").AppliesOn(monday))
+	assert.True(t, item("weekdays").AppliesOn(monday))
+	assert.False(t, item("weekdays").AppliesOn(first))
+	assert.True(t, item("1st").AppliesOn(first))
+	assert.True(t, item("last").AppliesOn(friday))
+	assert.False(t, item("last").AppliesOn(first))
+	assert.False(t, item("+oncall").AppliesOn(monday))
+	assert.True(t, item("+oncall").AppliesOn(monday, "oncall"))
+	assert.False(t, item("Fri, +oncall").AppliesOn(monday, "oncall"))
+	assert.True(t, item("Fri, +oncall").AppliesOn(friday, "oncall"))
+}
+
+func TestListFor(t *testing.T) {
+	monday := time.Date(2020, 2, 3, 0, 0, 0, 0, time.Local)
+	def := List{
+		&ListItem{Description: "Catch up on slack", Status: Status{Name: "DONE"}},
+		&ListItem{Description: "Plan the sprint", Schedule: "Mon"},
+		&ListItem{Description: "Write the weekly update", Schedule: "Fri"},
+		&ListItem{Description: "Check the pager queue", Schedule: "+oncall"},
+	}
+	day := def.For(monday, "oncall")
+	if !assert.Len(t, day, 3) {
+		return
+	}
+	assert.Equal(t, "Catch up on slack", day[0].Description)
+	assert.Equal(t, "", day[0].Status.Name)
+	assert.Equal(t, 1, day[0].number)
+	assert.Equal(t, "Plan the sprint", day[1].Description)
+	assert.Equal(t, 2, day[1].number)
+	assert.Equal(t, "Check the pager queue", day[2].Description)
+	assert.NotSame(t, def[0], day[0])
+}
+
+func TestListMerge(t *testing.T) {
+	monday := time.Date(2020, 2, 3, 0, 0, 0, 0, time.Local)
+	def := List{
+		&ListItem{Description: "Catch up on slack"},
+		&ListItem{Description: "Read the inbox"},
+		&ListItem{Description: "Write the weekly update", Schedule: "Fri"},
+	}
+	// "Read the inbox" was removed and "Water the plants" added on Monday. The Friday item wasn't
+	// part of Monday's Startup, so it is kept.
+	day := List{
+		&ListItem{Description: "Catch up on slack", Status: Status{Name: "DONE"}},
+		&ListItem{Description: "Water the plants", Schedule: "Mon"},
+	}
+	merged := def.Merge(day, monday)
+	if !assert.Len(t, merged, 3) {
+		return
+	}
+	assert.Equal(t, "Catch up on slack", merged[0].Description)
+	assert.Equal(t, "", merged[0].Status.Name)
+	assert.Equal(t, "Write the weekly update", merged[1].Description)
+	assert.Equal(t, "Water the plants", merged[2].Description)
+	assert.Equal(t, "Mon", merged[2].Schedule)
+	assert.Equal(t, 3, merged[2].number)
+}
+
+func TestListReadback(t *testing.T) {
+	l := List{
+		&ListItem{number: 1, Description: "Catch up on slack"},
+		&ListItem{number: 2, Description: "Plan the sprint", Schedule: "Mon"},
+		&ListItem{number: 3, Description: "Check email (work)", Status: Status{Name: "DONE"}},
+	}
+	var b strings.Builder
+	w := bufio.NewWriter(&b)
+	assert.NoError(t, l.Write(w))
+	w.Flush()
+	assert.Equal(t, "1. Catch up on slack\n2. Plan the sprint (Mon)\n3. Check email (work) [DONE]\n", b.String())
+	assert.Equal(t, l, ParseList(strings.NewReader(b.String())))
+}
+
+func TestListMergeTags(t *testing.T) {
+	monday := time.Date(2020, 2, 3, 0, 0, 0, 0, time.Local)
+	def := List{&ListItem{Description: "Check the pager queue", Schedule: "+oncall"}}
+	merged := def.Merge(nil, monday)
+	assert.Len(t, merged, 1)
+}
+
+func TestListMergeDates(t *testing.T) {
+	monday := time.Date(2020, 2, 3, 0, 0, 0, 0, time.Local)
+	prep := &ListItem{Description: "Prep for the review", Schedule: "2020-02-04"}
+	assert.False(t, prep.AppliesOn(monday))
+	assert.True(t, prep.AppliesOn(monday.AddDate(0, 0, 1)))
+
+	// A dated item added by hand is kept until its date is past.
+	merged := List(nil).Merge(List{{Description: "Prep for standup", Schedule: "2020-02-03"}, prep}, monday)
+	if !assert.Len(t, merged, 1) {
+		return
+	}
+	assert.Equal(t, "Prep for the review", merged[0].Description)
+	assert.Len(t, merged.Merge(merged.For(monday.AddDate(0, 0, 1)), monday.AddDate(0, 0, 1)), 0)
+}
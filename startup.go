@@ -0,0 +1,137 @@
+package today
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A StartupRecord records the Status name a Startup item had at the end of a day. Records are kept
+// so that Clear can wipe the Startup statuses without losing track of whether the morning routine
+// was actually done.
+type StartupRecord struct {
+	Date        time.Time
+	Description string
+	Status      string
+}
+
+// Completed reports whether the item was marked "DONE".
+func (r *StartupRecord) Completed() bool {
+	return r.Status == "DONE"
+}
+
+// Record returns a StartupRecord for each item in l, as of date.
+func (l List) Record(date time.Time) []StartupRecord {
+	y, m, d := date.Date()
+	date = time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	records := make([]StartupRecord, 0, len(l))
+	for _, item := range l {
+		records = append(records, StartupRecord{Date: date, Description: item.Description, Status: item.Status.Name})
+	}
+	return records
+}
+
+// WriteStartupRecords writes records to w, one per line, in the form:
+//   2020-01-05	DONE	Check the calendar
+// Fields are separated by tabs. Items without a status have an empty status field.
+func WriteStartupRecords(w io.Writer, records []StartupRecord) error {
+	bw := bufio.NewWriter(w)
+	for _, r := range records {
+		_, err := fmt.Fprintf(bw, "%s\t%s\t%s\n", r.Date.Format("2006-01-02"), r.Status, r.Description)
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ParseStartupRecords parses records written by WriteStartupRecords. If there is more than one
+// record for an item on the same day, the last one wins. The records are returned in date order.
+func ParseStartupRecords(r io.Reader) ([]StartupRecord, error) {
+	var (
+		records []StartupRecord
+		index   = make(map[string]int)
+	)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		fields := strings.SplitN(scanner.Text(), "\t", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected 3 fields, found %d", line, len(fields))
+		}
+		date, err := time.ParseInLocation("2006-01-02", fields[0], time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		rec := StartupRecord{Date: date, Status: fields[1], Description: fields[2]}
+		key := fields[0] + "\t" + fields[2]
+		if i, ok := index[key]; ok {
+			records[i] = rec
+			continue
+		}
+		index[key] = len(records)
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Date.Before(records[j].Date) })
+	return records, nil
+}
+
+// StartupItemStats summarizes how consistently a Startup item was completed. Days is the number of
+// days the item was part of the Startup section and Completed is how many of those it was marked
+// "DONE". Streak is the number of consecutive days, ending with the most recent one, on which the
+// item was completed.
+type StartupItemStats struct {
+	Description string
+	Days        int
+	Completed   int
+	Streak      int
+}
+
+// Rate returns the fraction of days the item was completed.
+func (s *StartupItemStats) Rate() float64 {
+	if s.Days == 0 {
+		return 0
+	}
+	return float64(s.Completed) / float64(s.Days)
+}
+
+// StartupStats computes completion statistics for each item in records dated on or after since.
+// records must be in date order. Items are returned in the order they first appear. Days on which
+// an item was not part of the Startup section, such as days outside its Schedule, don't count for
+// or against it.
+func StartupStats(records []StartupRecord, since time.Time) []*StartupItemStats {
+	var (
+		stats  []*StartupItemStats
+		byDesc = make(map[string]*StartupItemStats)
+	)
+	for i := range records {
+		r := &records[i]
+		if r.Date.Before(since) {
+			continue
+		}
+		s, ok := byDesc[r.Description]
+		if !ok {
+			s = &StartupItemStats{Description: r.Description}
+			byDesc[r.Description] = s
+			stats = append(stats, s)
+		}
+		s.Days++
+		if r.Completed() {
+			s.Completed++
+			s.Streak++
+		} else {
+			s.Streak = 0
+		}
+	}
+	return stats
+}
@@ -0,0 +1,69 @@
+package today
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartupRecords(t *testing.T) {
+	d := func(day int) time.Time { return time.Date(2020, 1, day, 0, 0, 0, 0, time.Local) }
+	l := List{
+		&ListItem{Description: "Catch up on slack", Status: Status{Name: "DONE"}},
+		&ListItem{Description: "Check the calendar"},
+	}
+	records := l.Record(d(5).Add(15 * time.Hour))
+	assert.Equal(t, []StartupRecord{
+		{Date: d(5), Description: "Catch up on slack", Status: "DONE"},
+		{Date: d(5), Description: "Check the calendar"},
+	}, records)
+
+	var b strings.Builder
+	assert.NoError(t, WriteStartupRecords(&b, records))
+	assert.Equal(t, "2020-01-05\tDONE\tCatch up on slack\n2020-01-05\t\tCheck the calendar\n", b.String())
+
+	// A later record for the same day and item replaces the earlier one.
+	text := b.String() + "2020-01-04\tDONE\tCheck the calendar\n2020-01-05\tDONE\tCheck the calendar\n"
+	parsed, err := ParseStartupRecords(strings.NewReader(text))
+	assert.NoError(t, err)
+	assert.Equal(t, []StartupRecord{
+		{Date: d(4), Description: "Check the calendar", Status: "DONE"},
+		{Date: d(5), Description: "Catch up on slack", Status: "DONE"},
+		{Date: d(5), Description: "Check the calendar", Status: "DONE"},
+	}, parsed)
+
+	_, err = ParseStartupRecords(strings.NewReader("2020-01-05 DONE\n"))
+	assert.Error(t, err)
+}
+
+func TestStartupStats(t *testing.T) {
+	d := func(day int) time.Time { return time.Date(2020, 1, day, 0, 0, 0, 0, time.Local) }
+	var records []StartupRecord
+	add := func(day int, desc, status string) {
+		records = append(records, StartupRecord{Date: d(day), Description: desc, Status: status})
+	}
+	add(1, "slack", "")
+	add(1, "plan", "DONE")
+	add(2, "slack", "DONE")
+	add(3, "slack", "")
+	add(4, "slack", "DONE")
+	add(5, "slack", "DONE")
+	add(8, "plan", "")
+
+	stats := StartupStats(records, d(2))
+	if !assert.Len(t, stats, 2) {
+		return
+	}
+	assert.Equal(t, "slack", stats[0].Description)
+	assert.Equal(t, 4, stats[0].Days)
+	assert.Equal(t, 3, stats[0].Completed)
+	assert.Equal(t, 2, stats[0].Streak)
+	assert.Equal(t, 0.75, stats[0].Rate())
+
+	assert.Equal(t, "plan", stats[1].Description)
+	assert.Equal(t, 1, stats[1].Days)
+	assert.Equal(t, 0, stats[1].Completed)
+	assert.Equal(t, 0, stats[1].Streak)
+}
@@ -0,0 +1,183 @@
+package today
+
+import (
+	"sort"
+	"time"
+)
+
+// TaskStats summarizes the life of a single task across a sequence of Days.
+//
+// FirstSeen and LastSeen are the dates of the first and last Days the task appeared in. Age is how
+// long the task has been around: until it was completed for "DONE" tasks, and until now for open
+// ones. InStatus records how long the task spent in each Status name, measured between the Days it
+// was seen in.
+//
+// A task that disappears from the today files without being marked "DONE" is counted as Removed,
+// with Completed set to the date of the first Day it was missing from. Removed tasks are closed, but
+// don't count toward Throughput. A closed task that shows up again with another status is reopened.
+type TaskStats struct {
+	Name        string
+	Description string
+	Status      Status
+	FirstSeen   time.Time
+	LastSeen    time.Time
+	Completed   time.Time
+	Removed     bool
+	Age         time.Duration
+	InStatus    map[string]time.Duration
+}
+
+// Open reports whether the task has not been completed.
+func (s *TaskStats) Open() bool {
+	return s.Completed.IsZero()
+}
+
+// WeekCount is the number of tasks completed in the week beginning on Week, a Monday.
+type WeekCount struct {
+	Week time.Time
+	Done int
+}
+
+// Stats holds task aging and throughput statistics computed from a sequence of Days.
+type Stats struct {
+	// Tasks holds one entry per task, in order of first appearance.
+	Tasks []*TaskStats
+	// Throughput holds the number of tasks completed each week, oldest first. Weeks in which
+	// nothing was completed are included so the series has no gaps.
+	Throughput []WeekCount
+}
+
+// ComputeStats walks days, which must be in date order, and computes statistics for every named
+// task in them. now is used to age tasks that are still open on the last Day.
+func ComputeStats(days []Day, now time.Time) *Stats {
+	var (
+		stats  Stats
+		byName = make(map[string]*TaskStats)
+		last   = make(map[string]time.Time)
+	)
+	for _, day := range days {
+		if day.Today == nil {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, task := range day.Today.Tasks.Tasks {
+			if task.Name == "" {
+				continue
+			}
+			seen[task.Name] = true
+			ts, ok := byName[task.Name]
+			if !ok {
+				ts = &TaskStats{
+					Name:      task.Name,
+					FirstSeen: day.Date,
+					InStatus:  make(map[string]time.Duration),
+				}
+				byName[task.Name] = ts
+				stats.Tasks = append(stats.Tasks, ts)
+			} else if ts.Open() {
+				// Attribute the time since the task was last seen to the status it had then.
+				ts.InStatus[statusName(&ts.Status)] += day.Date.Sub(last[task.Name])
+			} else if task.Status.Name != "DONE" {
+				// The task came back, so it wasn't finished after all. The time it was gone
+				// isn't attributed to any status.
+				ts.Completed = time.Time{}
+				ts.Removed = false
+			}
+			if !ts.Open() {
+				continue
+			}
+			ts.Description = task.Description
+			ts.Status = task.Status
+			ts.LastSeen = day.Date
+			last[task.Name] = day.Date
+			if task.Status.Name == "DONE" {
+				ts.Completed = task.Status.Date
+				if ts.Completed.IsZero() {
+					ts.Completed = day.Date
+				}
+			}
+		}
+		for _, ts := range stats.Tasks {
+			if ts.Open() && !seen[ts.Name] {
+				ts.InStatus[statusName(&ts.Status)] += day.Date.Sub(last[ts.Name])
+				ts.Completed = day.Date
+				ts.Removed = true
+			}
+		}
+	}
+
+	var lastDay time.Time
+	if len(days) > 0 {
+		lastDay = days[len(days)-1].Date
+	}
+	weeks := make(map[time.Time]int)
+	for _, ts := range stats.Tasks {
+		if ts.Open() {
+			ts.Age = now.Sub(ts.FirstSeen)
+			if ts.LastSeen.Equal(lastDay) && now.After(ts.LastSeen) {
+				ts.InStatus[statusName(&ts.Status)] += now.Sub(ts.LastSeen)
+			}
+			continue
+		}
+		ts.Age = ts.Completed.Sub(ts.FirstSeen)
+		if ts.Age < 0 {
+			ts.Age = 0
+		}
+		if !ts.Removed {
+			weeks[weekOf(ts.Completed)]++
+		}
+	}
+	stats.Throughput = throughput(weeks)
+	return &stats
+}
+
+// Oldest returns up to n open tasks, oldest first. If n is negative, all open tasks are returned.
+func (s *Stats) Oldest(n int) []*TaskStats {
+	var open []*TaskStats
+	for _, ts := range s.Tasks {
+		if ts.Open() {
+			open = append(open, ts)
+		}
+	}
+	sort.SliceStable(open, func(i, j int) bool {
+		return open[i].FirstSeen.Before(open[j].FirstSeen)
+	})
+	if n >= 0 && len(open) > n {
+		open = open[:n]
+	}
+	return open
+}
+
+func statusName(s *Status) string {
+	if s.isUnknown() {
+		return "?"
+	}
+	return s.Name
+}
+
+// weekOf returns midnight on the Monday beginning the week containing t.
+func weekOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(y, m, d-offset, 0, 0, 0, 0, t.Location())
+}
+
+func throughput(weeks map[time.Time]int) []WeekCount {
+	if len(weeks) == 0 {
+		return nil
+	}
+	var first, last time.Time
+	for w := range weeks {
+		if first.IsZero() || w.Before(first) {
+			first = w
+		}
+		if w.After(last) {
+			last = w
+		}
+	}
+	var counts []WeekCount
+	for w := first; !w.After(last); w = w.AddDate(0, 0, 7) {
+		counts = append(counts, WeekCount{Week: w, Done: weeks[w]})
+	}
+	return counts
+}
@@ -0,0 +1,119 @@
+package today
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeStats(t *testing.T) {
+	// Jan 6, 2020 is a Monday.
+	d := func(day int) time.Time { return time.Date(2020, 1, day, 0, 0, 0, 0, time.Local) }
+	days := []Day{
+		dayWith(d(6),
+			&Task{Name: "TASK-1", Description: "one", Status: Status{Name: "?", Date: d(6)}},
+			&Task{Name: "TASK-2", Description: "two", Status: Status{Name: "READY", Date: d(6)}},
+		),
+		dayWith(d(7),
+			&Task{Name: "TASK-1", Description: "one", Status: Status{Name: "IN PROGRESS", Date: d(7)}},
+			&Task{Name: "TASK-2", Description: "two", Status: Status{Name: "READY", Date: d(6)}},
+		),
+		dayWith(d(9),
+			&Task{Name: "TASK-1", Description: "one", Status: Status{Name: "DONE", Date: d(9)}},
+			&Task{Name: "TASK-2", Description: "two", Status: Status{Name: "READY", Date: d(6)}},
+			&Task{Name: "TASK-3", Description: "three", Status: Status{Name: "WAITING", Date: d(9)}},
+		),
+		dayWith(d(14),
+			&Task{Name: "TASK-2", Description: "two", Status: Status{Name: "DONE", Date: d(14)}},
+			&Task{Name: "TASK-3", Description: "three", Status: Status{Name: "WAITING", Date: d(9)}},
+		),
+	}
+	now := d(15)
+	stats := ComputeStats(days, now)
+	if !assert.Len(t, stats.Tasks, 3) {
+		return
+	}
+
+	one := stats.Tasks[0]
+	assert.Equal(t, "TASK-1", one.Name)
+	assert.False(t, one.Open())
+	assert.Equal(t, d(9), one.Completed)
+	assert.Equal(t, d(9).Sub(d(6)), one.Age)
+	assert.Equal(t, d(7).Sub(d(6)), one.InStatus["?"])
+	assert.Equal(t, d(9).Sub(d(7)), one.InStatus["IN PROGRESS"])
+
+	two := stats.Tasks[1]
+	assert.False(t, two.Open())
+	assert.Equal(t, d(14).Sub(d(6)), two.InStatus["READY"])
+
+	three := stats.Tasks[2]
+	assert.True(t, three.Open())
+	assert.Equal(t, now.Sub(d(9)), three.Age)
+	assert.Equal(t, now.Sub(d(9)), three.InStatus["WAITING"])
+
+	assert.Equal(t, []WeekCount{{Week: d(6), Done: 1}, {Week: d(13), Done: 1}}, stats.Throughput)
+
+	oldest := stats.Oldest(5)
+	if assert.Len(t, oldest, 1) {
+		assert.Equal(t, "TASK-3", oldest[0].Name)
+	}
+}
+
+func TestComputeStatsRemoved(t *testing.T) {
+	d := func(day int) time.Time { return time.Date(2020, 1, day, 0, 0, 0, 0, time.Local) }
+	days := []Day{
+		dayWith(d(6),
+			&Task{Name: "TASK-1", Description: "deleted", Status: Status{Name: "READY", Date: d(6)}},
+			&Task{Name: "TASK-2", Description: "reopened", Status: Status{Name: "DONE", Date: d(6)}},
+			&Task{Name: "TASK-3", Description: "back again", Status: Status{Name: "WAITING", Date: d(6)}},
+		),
+		dayWith(d(7),
+			&Task{Name: "TASK-4", Description: "new", Status: Status{Name: "READY", Date: d(7)}},
+		),
+		dayWith(d(8),
+			&Task{Name: "TASK-2", Description: "reopened", Status: Status{Name: "IN PROGRESS", Date: d(8)}},
+			&Task{Name: "TASK-3", Description: "back again", Status: Status{Name: "WAITING", Date: d(6)}},
+			&Task{Name: "TASK-4", Description: "new", Status: Status{Name: "READY", Date: d(7)}},
+		),
+	}
+	now := d(10)
+	stats := ComputeStats(days, now)
+	if !assert.Len(t, stats.Tasks, 4) {
+		return
+	}
+
+	deleted := stats.Tasks[0]
+	assert.False(t, deleted.Open())
+	assert.True(t, deleted.Removed)
+	assert.Equal(t, d(7), deleted.Completed)
+	assert.Equal(t, d(7).Sub(d(6)), deleted.Age)
+	assert.Equal(t, d(7).Sub(d(6)), deleted.InStatus["READY"])
+
+	reopened := stats.Tasks[1]
+	assert.True(t, reopened.Open())
+	assert.False(t, reopened.Removed)
+	assert.Equal(t, "IN PROGRESS", reopened.Status.Name)
+	assert.Equal(t, now.Sub(d(8)), reopened.InStatus["IN PROGRESS"])
+
+	back := stats.Tasks[2]
+	assert.True(t, back.Open())
+	assert.Equal(t, now.Sub(d(6)), back.Age)
+
+	// Only TASK-2's first DONE was ever a completion, and it was undone.
+	assert.Nil(t, stats.Throughput)
+
+	var names []string
+	for _, ts := range stats.Oldest(-1) {
+		names = append(names, ts.Name)
+	}
+	assert.Equal(t, []string{"TASK-2", "TASK-3", "TASK-4"}, names)
+}
+
+func TestWeekOf(t *testing.T) {
+	monday := time.Date(2020, 1, 6, 0, 0, 0, 0, time.Local)
+	for i := 0; i < 7; i++ {
+		assert.Equal(t, monday, weekOf(monday.AddDate(0, 0, i).Add(13*time.Hour)))
+	}
+	assert.Equal(t, monday.AddDate(0, 0, -7), weekOf(monday.Add(-time.Hour)))
+}
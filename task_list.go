@@ -55,7 +55,7 @@ type Task struct {
 }
 
 // Update adds dates and statuses to any todos without them. If log is not nil, it will add entries
-// to the log whenever it adds a date to a task's status.
+// to the log whenever it adds a date to a task's status. (See LogEntry)
 func (t *TaskList) Update(log *Lines) {
 	for _, todo := range t.Tasks {
 		if todo.Name == "" {
@@ -64,12 +64,11 @@ func (t *TaskList) Update(log *Lines) {
 		}
 		if todo.Status.Date.IsZero() {
 			todo.Status.Date = time.Now()
-			timestr := time.Now().Format("3:04")
 			if log != nil && !todo.Status.isUnknown() {
 				if todo.Status.Comment != "" {
-					log.Add(fmt.Sprintf("%s - Moved %s (%s) to %s (%s)", timestr, todo.Name, todo.Description, todo.Status.Name, todo.Status.Comment))
+					log.Append(NewLogEntry(fmt.Sprintf("Moved %s (%s) to %s (%s)", todo.Name, todo.Description, todo.Status.Name, todo.Status.Comment)))
 				} else {
-					log.Add(fmt.Sprintf("%s - Moved %s (%s) to  %s", timestr, todo.Name, todo.Description, todo.Status.Name))
+					log.Append(NewLogEntry(fmt.Sprintf("Moved %s (%s) to  %s", todo.Name, todo.Description, todo.Status.Name)))
 				}
 			}
 		}
@@ -156,28 +155,94 @@ func (s *Status) isUnknown() bool {
 	return s.Name == "" || s.Name == "?"
 }
 
-func priority(s *Status) int {
+// Priority returns the rank a task with this status is sorted by. Lower ranks sort first. (See
+// TaskList.Sort)
+func (s *Status) Priority() int {
+	return priority(s)
+}
+
+// Resurfaced describes why a task with this status has been sent to the top of the list despite
+// its status, or returns "" if it hasn't been. (See the sorting exceptions in TaskList.Sort)
+func (s *Status) Resurfaced() string {
+	return resurfaced(s, time.Now())
+}
+
+func resurfaced(s *Status, now time.Time) string {
 	// Tasks marked "HOLD" should be held until the specified date.
-	if s.Name == "HOLD" && time.Now().After(s.Date) {
-		return 0
+	if s.Name == "HOLD" && now.After(s.Date) {
+		return fmt.Sprintf("HOLD until %s has arrived", s.Date.Format("Jan _2, 2006"))
 	}
 
 	// Tasks waiting or in review should be checked again after a day.
 	if (s.Name == "WAITING" || s.Name == "REVIEW" || s.Name == "RESPONDED") &&
-		time.Now().After(s.Date.Add(24*time.Hour)) {
-		return 0
+		now.After(s.Date.Add(24*time.Hour)) {
+		return fmt.Sprintf("%s for %s (>24h)", s.Name, daysSince(s.Date, now))
 	}
 
 	// Check up on stale tasks once per week
-	if s.Name == "STALE" && time.Now().After(s.Date.Add(24*7*time.Hour)) {
-		return 0
+	if s.Name == "STALE" && now.After(s.Date.Add(24*7*time.Hour)) {
+		return fmt.Sprintf("STALE for %s (>1 week)", daysSince(s.Date, now))
+	}
+	return ""
+}
+
+func daysSince(t, now time.Time) string {
+	days := int(now.Sub(t).Hours() / 24)
+	if days == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
+
+func priority(s *Status) int {
+	r, _ := rank(s, time.Now())
+	return r
+}
+
+// rank returns the priority of a status along with a description of why it has that priority.
+func rank(s *Status, now time.Time) (int, string) {
+	if reason := resurfaced(s, now); reason != "" {
+		return 0, reason + ", resurfaced"
 	}
 
 	v, ok := priorityOrder[s.Name]
 	if !ok {
-		return priorityOrder["OTHER"]
+		return priorityOrder["OTHER"], fmt.Sprintf("unknown status %s, sorted with new tasks", s.Name)
+	}
+	switch {
+	case s.isUnknown():
+		return v, "no status yet"
+	case s.Date.IsZero():
+		return v, s.Name
+	case s.Name == "HOLD":
+		return v, fmt.Sprintf("HOLD until %s", s.Date.Format("Jan _2, 2006"))
+	}
+	return v, fmt.Sprintf("%s since %s", s.Name, s.Date.Format("Jan _2, 2006"))
+}
+
+// A Ranking explains where Sort puts a task. Tasks are sorted by Rank, lowest first, and then by
+// date. Reason describes how the Rank was chosen, for instance:
+//   WAITING for 3 days (>24h), resurfaced
+type Ranking struct {
+	Task   *Task
+	Rank   int
+	Reason string
+}
+
+// Explain returns the tasks in the order Sort would put them, along with each task's rank and the
+// reason for it. It does not change the TaskList.
+func (t *TaskList) Explain() []Ranking {
+	tasks := make([]*Task, len(t.Tasks))
+	copy(tasks, t.Tasks)
+	sort.Stable(byPriority(tasks))
+
+	now := time.Now()
+	rankings := make([]Ranking, 0, len(tasks))
+	for _, task := range tasks {
+		r, reason := rank(&task.Status, now)
+		rankings = append(rankings, Ranking{Task: task, Rank: r, Reason: reason})
 	}
-	return v
+	return rankings
 }
 
 type byPriority []*Task
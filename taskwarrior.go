@@ -0,0 +1,174 @@
+package today
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// taskwarriorTime is the format of dates in Taskwarrior's JSON.
+const taskwarriorTime = "20060102T150405Z"
+
+// taskwarriorTask is a task in Taskwarrior's JSON export format. The Today* fields are
+// user-defined attributes holding what Taskwarrior has no place for, so that tasks exported from a
+// today file import back unchanged.
+type taskwarriorTask struct {
+	UUID        string                  `json:"uuid,omitempty"`
+	Description string                  `json:"description"`
+	Status      string                  `json:"status"`
+	Entry       string                  `json:"entry,omitempty"`
+	Modified    string                  `json:"modified,omitempty"`
+	Start       string                  `json:"start,omitempty"`
+	End         string                  `json:"end,omitempty"`
+	Due         string                  `json:"due,omitempty"`
+	Wait        string                  `json:"wait,omitempty"`
+	Project     string                  `json:"project,omitempty"`
+	Tags        []string                `json:"tags,omitempty"`
+	Annotations []taskwarriorAnnotation `json:"annotations,omitempty"`
+
+	TodayName    string `json:"todayname,omitempty"`
+	TodayStatus  string `json:"todaystatus,omitempty"`
+	TodayComment string `json:"todaycomment,omitempty"`
+}
+
+type taskwarriorAnnotation struct {
+	Entry       string `json:"entry"`
+	Description string `json:"description"`
+}
+
+func parseTaskwarriorTime(s string) time.Time {
+	t, err := time.Parse(taskwarriorTime, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t.Local()
+}
+
+func formatTaskwarriorTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(taskwarriorTime)
+}
+
+// taskwarriorUUID makes a stable name-based (version 5 style) UUID for a task name, so that
+// exporting the same task twice gives it the same UUID.
+func taskwarriorUUID(name string) string {
+	h := sha1.Sum([]byte("today:" + name))
+	h[6] = (h[6] & 0x0f) | 0x50
+	h[8] = (h[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", h[0:4], h[4:6], h[6:8], h[8:10], h[10:16])
+}
+
+// WriteTaskwarrior writes the tasks of t to w as a Taskwarrior JSON array, suitable for
+// "task import". "DONE" tasks are completed, "HOLD" tasks wait until their date, and "IN PROGRESS"
+// tasks are started. Keyed comments for "uuid", "due", "tags" and "project" fill in those fields,
+// and the other comments become annotations. Tasks without a "uuid" comment are given one based on
+// their Name. (See ParseTaskwarrior)
+func (t *TaskList) WriteTaskwarrior(w io.Writer) error {
+	out := make([]taskwarriorTask, 0, len(t.Tasks))
+	for _, task := range t.Tasks {
+		date := formatTaskwarriorTime(task.Status.Date)
+		tw := taskwarriorTask{
+			Description: task.Description,
+			Status:      "pending",
+			Entry:       date,
+			Modified:    date,
+			TodayName:   task.Name,
+		}
+		switch task.Status.Name {
+		case "DONE":
+			tw.Status, tw.End = "completed", date
+		case "HOLD":
+			tw.Wait = date
+		case "IN PROGRESS":
+			tw.Start = date
+		case "", "?":
+		default:
+			tw.TodayStatus = task.Status.Name
+		}
+		tw.TodayComment = task.Status.Comment
+
+		for _, c := range task.Comments {
+			k, v, ok := splitNote(c)
+			switch {
+			case ok && k == "uuid":
+				tw.UUID = v
+			case ok && k == "due":
+				if due, err := time.ParseInLocation("2006-01-02", v, time.Local); err == nil {
+					tw.Due = formatTaskwarriorTime(due)
+				} else {
+					tw.Annotations = append(tw.Annotations, taskwarriorAnnotation{date, c})
+				}
+			case ok && k == "tags":
+				tw.Tags = strings.Fields(v)
+			case ok && k == "project":
+				tw.Project = v
+			default:
+				tw.Annotations = append(tw.Annotations, taskwarriorAnnotation{date, c})
+			}
+		}
+		if tw.UUID == "" && task.Name != "" {
+			tw.UUID = taskwarriorUUID(task.Name)
+		}
+		out = append(out, tw)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// ParseTaskwarrior parses tasks from Taskwarrior's JSON, as written by "task export". Completed
+// tasks are "DONE" as of when they ended, tasks with a wait date are on "HOLD" until then, and
+// started tasks are "IN PROGRESS". Other pending tasks are new ("?"), and deleted tasks are left
+// out. The UUID, due date, tags and project are kept in keyed comments ("uuid: ...", "due:
+// 2020-01-10", "tags: a b", "project: ..."), followed by the annotations. Since TaskList.Merge
+// matches tasks by their "uuid" comment, importing the same tasks twice doesn't duplicate them.
+func ParseTaskwarrior(r io.Reader) ([]*Task, error) {
+	var in []taskwarriorTask
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, err
+	}
+	var tasks []*Task
+	for _, tw := range in {
+		if tw.Status == "deleted" {
+			continue
+		}
+		task := &Task{Name: tw.TodayName, Description: tw.Description}
+		wait := parseTaskwarriorTime(tw.Wait)
+		switch {
+		case tw.Status == "completed":
+			task.Status = Status{Name: "DONE", Date: parseTaskwarriorTime(tw.End)}
+		case !wait.IsZero():
+			task.Status = Status{Name: "HOLD", Date: wait}
+		case tw.Start != "":
+			task.Status = Status{Name: "IN PROGRESS", Date: parseTaskwarriorTime(tw.Start)}
+		case tw.TodayStatus != "":
+			task.Status = Status{Name: tw.TodayStatus, Date: parseTaskwarriorTime(tw.Modified)}
+		default:
+			task.Status = Status{Name: "?", Date: parseTaskwarriorTime(tw.Entry)}
+		}
+		task.Status.Comment = tw.TodayComment
+
+		if tw.UUID != "" && (tw.TodayName == "" || tw.UUID != taskwarriorUUID(tw.TodayName)) {
+			task.Comments = append(task.Comments, "uuid: "+tw.UUID)
+		}
+		if due := parseTaskwarriorTime(tw.Due); !due.IsZero() {
+			task.Comments = append(task.Comments, "due: "+due.Format("2006-01-02"))
+		}
+		if len(tw.Tags) > 0 {
+			task.Comments = append(task.Comments, "tags: "+strings.Join(tw.Tags, " "))
+		}
+		if tw.Project != "" {
+			task.Comments = append(task.Comments, "project: "+tw.Project)
+		}
+		for _, a := range tw.Annotations {
+			task.Comments = append(task.Comments, a.Description)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
@@ -0,0 +1,74 @@
+package today
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskwarrior(t *testing.T) {
+	d := time.Date(2020, 1, 5, 0, 0, 0, 0, time.Local)
+	list := TaskList{Tasks: []*Task{
+		{Name: "JIRA-12", Description: "Fix the thing", Status: Status{Name: "IN PROGRESS", Comment: "pr #3", Date: d}, Comments: []string{"due: 2020-01-10", "tags: work urgent", "step 1"}},
+		{Name: "TASK-4", Description: "Later", Status: Status{Name: "HOLD", Date: d.AddDate(0, 0, 7)}},
+		{Name: "TASK-5", Description: "Ask", Status: Status{Name: "WAITING", Date: d}, Comments: []string{"uuid: 1f1e7d0a-0000-4000-8000-000000000001"}},
+		{Name: "TASK-6", Description: "Write docs", Status: Status{Name: "DONE", Date: d}},
+		{Name: "TASK-7", Description: "New", Status: Status{Name: "?", Date: d}},
+	}}
+
+	var b bytes.Buffer
+	assert.NoError(t, list.WriteTaskwarrior(&b))
+	tasks, err := ParseTaskwarrior(bytes.NewReader(b.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, list.Tasks, tasks)
+
+	assert.Equal(t, taskwarriorUUID("TASK-4"), taskwarriorUUID("TASK-4"))
+	assert.NotEqual(t, taskwarriorUUID("TASK-4"), taskwarriorUUID("TASK-6"))
+	assert.Contains(t, b.String(), `"uuid": "`+taskwarriorUUID("TASK-4")+`"`)
+	assert.Contains(t, b.String(), `"uuid": "1f1e7d0a-0000-4000-8000-000000000001"`)
+}
+
+func TestParseTaskwarrior(t *testing.T) {
+	in := `[
+{"uuid":"a3c1","description":"Fix the sink","status":"pending","entry":"20200105T000000Z","tags":["home"],"project":"house","annotations":[{"entry":"20200105T000000Z","description":"call plumber"}]},
+{"uuid":"b4d2","description":"Gone","status":"deleted"},
+{"uuid":"c5e3","description":"Pay bills","status":"completed","end":"20200107T120000Z"},
+{"uuid":"d6f4","description":"Later","status":"waiting","wait":"20200201T000000Z","due":"20200210T000000Z"}
+]`
+	tasks, err := ParseTaskwarrior(strings.NewReader(in))
+	assert.NoError(t, err)
+	if !assert.Len(t, tasks, 3) {
+		return
+	}
+	assert.Equal(t, "?", tasks[0].Status.Name)
+	assert.Equal(t, []string{"uuid: a3c1", "tags: home", "project: house", "call plumber"}, tasks[0].Comments)
+	assert.Equal(t, "DONE", tasks[1].Status.Name)
+	assert.Equal(t, time.Date(2020, 1, 7, 12, 0, 0, 0, time.UTC).Local(), tasks[1].Status.Date)
+	assert.Equal(t, "HOLD", tasks[2].Status.Name)
+	assert.Contains(t, tasks[2].Comments, "due: "+time.Date(2020, 2, 10, 0, 0, 0, 0, time.UTC).Local().Format("2006-01-02"))
+
+	// Importing the same tasks twice doesn't duplicate them.
+	var list TaskList
+	list.Merge(tasks)
+	list.Update(nil)
+	again, _ := ParseTaskwarrior(strings.NewReader(in))
+	again[0].Status.Name = "READY"
+	list.Merge(again)
+	assert.Len(t, list.Tasks, 3)
+	// A changed status is left for Update to date, rather than keeping the imported entry date.
+	assert.Equal(t, Status{Name: "READY"}, list.Tasks[0].Status)
+
+	// A wait date comes through Merge and Update as the HOLD's release date.
+	wait := time.Now().AddDate(0, 1, 0).Truncate(time.Second)
+	later, err := ParseTaskwarrior(strings.NewReader(`[{"uuid":"d6f4","description":"Later","status":"waiting","wait":"` + formatTaskwarriorTime(wait) + `"}]`))
+	assert.NoError(t, err)
+	held := TaskList{Tasks: []*Task{{Name: "TASK-1", Description: "Later", Status: Status{Name: "?", Date: time.Now()}}}}
+	later[0].Name = "TASK-1"
+	held.Merge(later)
+	held.Update(nil)
+	assert.Equal(t, "HOLD", held.Tasks[0].Status.Name)
+	assert.True(t, wait.Equal(held.Tasks[0].Status.Date))
+}
@@ -1,6 +1,7 @@
 package today
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -36,8 +37,9 @@ import (
 // added to the "Log" section with a timestamp and a description of the applied status. For
 // example, when Update() notices a task with a new status without a date (e.g. "TASK-123 - Do
 // something important [DONE - Finished up]"), It Makes an entry in the log like so:
-//   4:48 - Moved TASK-123 (Do something important) to DONE (Finished up)
+//   4:48PM - Moved TASK-123 (Do something important) to DONE (Finished up)
 // I also add my own timestamped entries to the Log when I want to record some important event.
+// Each line of the Log can be parsed as a LogEntry.
 //
 // Tasks
 //
@@ -72,9 +74,22 @@ type Status struct {
 }
 
 // ListItem represents one line of a List. Lists are ordered and have a Description and optional Status.
+//
+// A ListItem may also have a Schedule, written in parentheses at the end of its Description, which
+// limits the days the item is part of the Startup section:
+//   1. Plan the sprint (Mon)
+//   2. Write the weekly update (Fri)
+//   3. Pay the bills (1st, 15th)
+//   4. Check the pager queue (+oncall)
+// A Schedule is a comma-separated list of terms. Weekday names ("Mon", "Tuesday"), "weekdays",
+// "weekends", days of the month ("1st", "22nd"), "last" (the last day of the month) and single
+// dates ("2020-01-05") are dates, and the item applies if any of them matches. Terms beginning with
+// "+" are tags, and the item only applies while all of its tags are in effect. Parentheses
+// containing anything else are simply part of the Description.
 type ListItem struct {
 	number      int
 	Description string
+	Schedule    string
 	Status      Status
 }
 
@@ -114,13 +129,48 @@ func (l List) Update() {
 	}
 }
 
-// Update makes sure items in Startup are numbered correctly, and applies statuses to un-statused
-// items in the Tasks section. (See TaskList.Update and List.Update)
+// UpdateLog numbers the items like Update, and like TaskList.Update, it also adds the current date
+// to any item with a Status but no date. If log is not nil, it adds an entry to the log whenever it
+// does so. For example, when UpdateLog notices "2. Check the calendar [DONE]", it makes an entry in
+// the log like so:
+//   9:12AM - Completed startup item 2 (Check the calendar)
+func (l List) UpdateLog(log *Lines) {
+	l.Update()
+	for _, item := range l {
+		if item.Status.Name == "" && item.Status.Comment == "" {
+			continue
+		}
+		if item.Status.Date.IsZero() {
+			item.Status.Date = time.Now()
+			if log != nil && !item.Status.isUnknown() {
+				switch {
+				case item.Status.Name == "DONE":
+					log.Append(NewLogEntry(fmt.Sprintf("Completed startup item %d (%s)", item.number, item.Description)))
+				case item.Status.Comment != "":
+					log.Append(NewLogEntry(fmt.Sprintf("Moved startup item %d (%s) to %s (%s)", item.number, item.Description, item.Status.Name, item.Status.Comment)))
+				default:
+					log.Append(NewLogEntry(fmt.Sprintf("Moved startup item %d (%s) to %s", item.number, item.Description, item.Status.Name)))
+				}
+			}
+		}
+	}
+}
+
+// Update makes sure items in Startup are numbered correctly, and applies dates to new statuses in
+// both the Startup and Tasks sections, logging them in the Log section. (See TaskList.Update and
+// List.UpdateLog)
 func (t *Today) Update() {
-	t.Startup.Update()
+	t.Startup.UpdateLog(&t.Log)
 	t.Tasks.Update(&t.Log)
 }
 
+// Format renumbers the Startup items as Update does, but adds no dates, task names or Log entries,
+// so that writing t afterward only changes the layout of the file it was parsed from. (See
+// List.Update)
+func (t *Today) Format() {
+	t.Startup.Update()
+}
+
 // Sort sorts the Tasks section (See Tasks.Sort)
 func (t *Today) Sort() {
 	t.Tasks.Sort()
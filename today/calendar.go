@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/knusbaum/today"
+)
+
+// importCalendarCmd adds a Log entry for each of today's events in an iCalendar file to the current
+// today file. With -prep, it also adds a Startup item for today only to prepare for each event.
+// Running it again with the same file adds nothing new.
+func importCalendarCmd(dir string, args []string) error {
+	fs := flag.NewFlagSet("import-calendar", flag.ExitOnError)
+	prep := fs.Bool("prep", false, "Also add a Startup item to prepare for each event.")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: today import-calendar [-prep] FILE.ics\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	events, err := today.ParseICalEvents(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	type occurrence struct {
+		at    time.Time
+		event today.Event
+	}
+	now := time.Now()
+	var todays []occurrence
+	for _, e := range events {
+		if e.AllDay {
+			continue
+		}
+		if at, ok := e.On(now); ok {
+			todays = append(todays, occurrence{at, e})
+		}
+	}
+	sort.Slice(todays, func(i, j int) bool { return todays[i].at.Before(todays[j].at) })
+
+	return updateToday(dir, func(t *today.Today) error {
+		for _, o := range todays {
+			message := o.event.Summary
+			if o.event.Location != "" {
+				message += " (" + o.event.Location + ")"
+			}
+			entry := today.NewLogEntryAt(o.at, message)
+			if t.Log.Insert(entry, now) {
+				fmt.Printf("log: %s\n", entry)
+			}
+			if !*prep {
+				continue
+			}
+			item := fmt.Sprintf("Prep for %s at %s", o.event.Summary, o.at.Format(time.Kitchen))
+			found := false
+			for _, existing := range t.Startup {
+				found = found || existing.Description == item
+			}
+			if !found {
+				// Scheduled for today only, so that the item doesn't carry over to tomorrow.
+				t.Startup = append(t.Startup, &today.ListItem{Description: item, Schedule: now.Format("2006-01-02")})
+				fmt.Printf("startup: %s\n", item)
+			}
+		}
+		t.Startup.Update()
+		return nil
+	})
+}
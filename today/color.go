@@ -0,0 +1,39 @@
+package main
+
+// ANSI escape sequences used to color statuses on the terminal.
+const (
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiDim     = "\x1b[2m"
+	ansiReverse = "\x1b[7m"
+	ansiRed     = "\x1b[31m"
+	ansiGreen   = "\x1b[32m"
+	ansiYellow  = "\x1b[33m"
+	ansiBlue    = "\x1b[34m"
+	ansiMagenta = "\x1b[35m"
+	ansiCyan    = "\x1b[36m"
+)
+
+var statusColors = map[string]string{
+	"":            ansiYellow,
+	"?":           ansiYellow,
+	"IN PROGRESS": ansiBold + ansiGreen,
+	"IN-PROGRESS": ansiBold + ansiGreen,
+	"INPROGRESS":  ansiBold + ansiGreen,
+	"READY":       ansiCyan,
+	"REVIEW":      ansiMagenta,
+	"WAITING":     ansiBlue,
+	"RESPONDED":   ansiBlue,
+	"STALE":       ansiDim + ansiYellow,
+	"HOLD":        ansiDim,
+	"DONE":        ansiDim + ansiGreen,
+}
+
+// statusColor returns the escape sequence used to color a status with the given name. Statuses
+// today doesn't know about are red, since they should be given one of the known statuses.
+func statusColor(name string) string {
+	if c, ok := statusColors[name]; ok {
+		return c
+	}
+	return ansiRed
+}
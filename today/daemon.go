@@ -0,0 +1,305 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/knusbaum/today"
+	"github.com/knusbaum/today/jsonrpc"
+)
+
+// daemonSocket is the default name of the Unix socket today daemon listens on, in the today
+// directory.
+const daemonSocket = ".today.sock"
+
+// daemon answers JSON-RPC requests about the today file in dir, and tells its clients when the
+// file changes.
+type daemon struct {
+	dir string
+
+	mu    sync.Mutex
+	conns map[*jsonrpc.Conn]bool
+	// wrote is the today file as the daemon last left it, so that watch doesn't report the
+	// daemon's own changes.
+	wrote os.FileInfo
+}
+
+// todayChanged is the parameter of the today.changed notification.
+type todayChanged struct {
+	File string `json:"file"`
+	Date string `json:"date"`
+}
+
+func (d *daemon) handle(method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case "tasks.list":
+		var p struct {
+			Status string `json:"status"`
+		}
+		if err := jsonrpc.Params(params, &p); err != nil {
+			return nil, err
+		}
+		t, err := readToday(d.dir)
+		if err != nil {
+			return nil, err
+		}
+		t.Sort()
+		tasks := []jsonTask{}
+		for _, task := range t.Tasks.Tasks {
+			if p.Status == "" || task.Status.Name == strings.ToUpper(p.Status) {
+				tasks = append(tasks, toJSONTask(task))
+			}
+		}
+		return tasks, nil
+
+	case "tasks.get":
+		var p struct {
+			Name string `json:"name"`
+		}
+		if err := jsonrpc.Params(params, &p); err != nil {
+			return nil, err
+		}
+		t, err := readToday(d.dir)
+		if err != nil {
+			return nil, err
+		}
+		task := t.Tasks.Find(p.Name)
+		if task == nil {
+			return nil, jsonrpc.Errorf(jsonrpc.InvalidParams, "no task named %s", p.Name)
+		}
+		return toJSONTask(task), nil
+
+	case "tasks.add":
+		var c taskChange
+		if err := jsonrpc.Params(params, &c); err != nil {
+			return nil, err
+		}
+		if c.Description == nil || *c.Description == "" {
+			return nil, jsonrpc.Errorf(jsonrpc.InvalidParams, "a task needs a description")
+		}
+		if err := c.check(); err != nil {
+			return nil, jsonrpc.Errorf(jsonrpc.InvalidParams, "%v", err)
+		}
+		task := &today.Task{Name: c.Name}
+		err := d.update(func(t *today.Today) error {
+			if c.Name != "" && t.Tasks.Find(c.Name) != nil {
+				return jsonrpc.Errorf(jsonrpc.InvalidParams, "there is already a task named %s", c.Name)
+			}
+			c.apply(task)
+			t.Tasks.Tasks = append(t.Tasks.Tasks, task)
+			t.Update()
+			c.applyHold(task)
+			t.Sort()
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return toJSONTask(task), nil
+
+	case "tasks.setStatus":
+		var p struct {
+			Name    string `json:"name"`
+			Status  string `json:"status"`
+			Comment string `json:"comment"`
+		}
+		if err := jsonrpc.Params(params, &p); err != nil {
+			return nil, err
+		}
+		if err := oneLine(p.Status, p.Comment); err != nil {
+			return nil, jsonrpc.Errorf(jsonrpc.InvalidParams, "%v", err)
+		}
+		var task *today.Task
+		err := d.update(func(t *today.Today) error {
+			if task = t.Tasks.Find(p.Name); task == nil {
+				return jsonrpc.Errorf(jsonrpc.InvalidParams, "no task named %s", p.Name)
+			}
+			// The new status has no date, so Update dates and logs it.
+			task.Status = today.Status{Name: strings.ToUpper(p.Status), Comment: p.Comment}
+			t.Update()
+			t.Sort()
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return toJSONTask(task), nil
+
+	case "log.append":
+		var p struct {
+			Message string `json:"message"`
+		}
+		if err := jsonrpc.Params(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Message == "" {
+			return nil, jsonrpc.Errorf(jsonrpc.InvalidParams, "a log entry needs a message")
+		}
+		if err := oneLine(p.Message); err != nil {
+			return nil, jsonrpc.Errorf(jsonrpc.InvalidParams, "%v", err)
+		}
+		e := today.NewLogEntry(p.Message)
+		err := d.update(func(t *today.Today) error {
+			t.Log.Append(e)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return toJSONLogEntry(e), nil
+
+	case "today.update":
+		tasks := []jsonTask{}
+		err := d.update(func(t *today.Today) error {
+			t.Update()
+			t.Sort()
+			for _, task := range t.Tasks.Tasks {
+				tasks = append(tasks, toJSONTask(task))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return tasks, nil
+
+	case "today.rollover":
+		generated, err := rolloverToday(d.dir)
+		if generated {
+			d.recordWrite()
+		}
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"date":      time.Now().Format("2006-01-02"),
+			"generated": generated,
+		}, nil
+	}
+	return nil, jsonrpc.Errorf(jsonrpc.MethodNotFound, "no method %s", method)
+}
+
+// update changes the today file with updateToday, and records the change as the daemon's own.
+func (d *daemon) update(change func(t *today.Today) error) error {
+	err := updateToday(d.dir, change)
+	if err == nil {
+		d.recordWrite()
+	}
+	return err
+}
+
+// recordWrite records the current day's today file as the daemon has just written it.
+func (d *daemon) recordWrite() {
+	fi, err := os.Stat(path.Join(d.dir, time.Now().Format(noteFormat)))
+	if err != nil {
+		return
+	}
+	d.mu.Lock()
+	d.wrote = fi
+	d.mu.Unlock()
+}
+
+// sameFile reports whether a and b describe the same version of the same file.
+func sameFile(a, b os.FileInfo) bool {
+	return a != nil && b != nil && a.Name() == b.Name() && a.ModTime().Equal(b.ModTime()) && a.Size() == b.Size()
+}
+
+// notify sends a notification to every client.
+func (d *daemon) notify(method string, params interface{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for c := range d.conns {
+		c.Notify(method, params)
+	}
+}
+
+// watch polls the most recent today file every interval, and sends today.changed to the clients
+// whenever it changes, or a new one is generated, other than by the daemon itself.
+func (d *daemon) watch(interval time.Duration) {
+	var last os.FileInfo
+	for range time.Tick(interval) {
+		files, err := noteFiles(d.dir)
+		if err != nil {
+			continue
+		}
+		fi, err := os.Stat(path.Join(d.dir, files[0].name))
+		if err != nil {
+			continue
+		}
+		d.mu.Lock()
+		own := sameFile(fi, d.wrote)
+		d.mu.Unlock()
+		if last != nil && !sameFile(fi, last) && !own {
+			d.notify("today.changed", todayChanged{File: fi.Name(), Date: files[0].date.Format("2006-01-02")})
+		}
+		last = fi
+	}
+}
+
+func (d *daemon) serve(c net.Conn) {
+	defer c.Close()
+	conn := jsonrpc.NewConn(c, c)
+	d.mu.Lock()
+	d.conns[conn] = true
+	d.mu.Unlock()
+	if err := conn.Serve(d.handle); err != nil {
+		log.Printf("Client error: %s", err)
+	}
+	d.mu.Lock()
+	delete(d.conns, conn)
+	d.mu.Unlock()
+}
+
+// daemonCmd serves JSON-RPC for editor plugins and other tools on a Unix socket.
+func daemonCmd(dir string, args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	socket := fs.String("socket", path.Join(dir, daemonSocket), "The Unix socket to listen on.")
+	poll := fs.Duration("poll", time.Second, "How often to check the today file for changes.")
+	fs.Parse(args)
+
+	// A socket left behind by a daemon that didn't exit cleanly is removed, but a running daemon
+	// is left alone.
+	if c, err := net.Dial("unix", *socket); err == nil {
+		c.Close()
+		return fmt.Errorf("already running on %s", *socket)
+	}
+	os.Remove(*socket)
+	l, err := net.Listen("unix", *socket)
+	if err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		close(done)
+		l.Close()
+	}()
+
+	d := &daemon{dir: dir, conns: make(map[*jsonrpc.Conn]bool)}
+	go d.watch(*poll)
+	log.Printf("Serving %s on %s", dir, *socket)
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			select {
+			case <-done:
+				return nil
+			default:
+				return err
+			}
+		}
+		go d.serve(c)
+	}
+}
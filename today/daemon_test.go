@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/knusbaum/today/jsonrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDaemonValidation(t *testing.T) {
+	dir := noteTestDir(t)
+	defer os.RemoveAll(dir)
+	d := &daemon{dir: dir, conns: make(map[*jsonrpc.Conn]bool)}
+	_, err := d.handle("tasks.add", json.RawMessage(`{"name": "JIRA-1", "description": "Write it"}`))
+	assert.NoError(t, err)
+	name := path.Join(dir, time.Now().Format(noteFormat))
+	before, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct{ method, params string }{
+		{"tasks.add", `{"name": "jira-2", "description": "Write it"}`},
+		{"tasks.add", `{"description": "Write it\nTODO:"}`},
+		{"tasks.add", `{"description": "Write it", "add_comment": "a\rb"}`},
+		{"tasks.setStatus", `{"name": "JIRA-1", "status": "done\nLog:"}`},
+		{"tasks.setStatus", `{"name": "JIRA-1", "status": "done", "comment": "a\nb"}`},
+		{"log.append", `{"message": "Lunch\nTODO:"}`},
+	} {
+		_, err := d.handle(c.method, json.RawMessage(c.params))
+		if e, ok := err.(*jsonrpc.Error); !ok || e.Code != jsonrpc.InvalidParams {
+			t.Errorf("%s %s: got %v, want an InvalidParams error", c.method, c.params, err)
+		}
+	}
+	after, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(before), string(after))
+}
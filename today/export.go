@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/knusbaum/today"
+)
+
+// exporters write a today file out in another format, keyed by the name given to -format.
+var exporters = map[string]func(w io.Writer, t *today.Today) error{
+	"csv":         func(w io.Writer, t *today.Today) error { return t.Tasks.WriteCSV(w) },
+	"ics":         func(w io.Writer, t *today.Today) error { return t.Tasks.WriteICal(w) },
+	"md":          func(w io.Writer, t *today.Today) error { return t.WriteMarkdown(w) },
+	"org":         func(w io.Writer, t *today.Today) error { return t.WriteOrg(w) },
+	"taskwarrior": func(w io.Writer, t *today.Today) error { return t.Tasks.WriteTaskwarrior(w) },
+	"todotxt":     func(w io.Writer, t *today.Today) error { return t.Tasks.WriteTodoTxt(w) },
+}
+
+// exportCmd writes the current today file to stdout, or to the file given by -o, in another format.
+// With -history, it instead writes the tasks of every today file as CSV.
+func exportCmd(dir string, args []string) error {
+	var names []string
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "md", "The format to export to. One of: "+strings.Join(names, ", "))
+	fs.StringVar(format, "to", "md", "Same as -format.")
+	out := fs.String("o", "", "Write to this file rather than stdout.")
+	history := fs.Bool("history", false, "Write a row for every task of every today file. Only for -format csv.")
+	fs.Parse(args)
+
+	var write func(w io.Writer) error
+	if *history {
+		if *format != "csv" {
+			return fmt.Errorf("-history is only supported with -format csv")
+		}
+		days, err := loadDays(dir)
+		if err != nil {
+			return err
+		}
+		write = func(w io.Writer) error { return today.WriteHistoryCSV(w, days) }
+	} else {
+		export, ok := exporters[*format]
+		if !ok {
+			return fmt.Errorf("unknown export format %q", *format)
+		}
+		t, err := readToday(dir)
+		if err != nil {
+			return err
+		}
+		write = func(w io.Writer) error { return export(w, t) }
+	}
+
+	if *out == "" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/knusbaum/today"
+)
+
+// diffContext is the number of unchanged lines shown around each change by unifiedDiff.
+const diffContext = 3
+
+// splitLines splits s into lines, keeping their newlines.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// hunkRange formats the start and length of the lines of a file in a hunk header, leaving out a
+// length of 1 as diff -u does.
+func hunkRange(start, n int) string {
+	if n == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, n)
+}
+
+// unifiedDiff returns the changes from a to b as a unified diff, as printed by diff -u, or "" if
+// they are the same.
+func unifiedDiff(aName, bName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	al, bl := splitLines(a), splitLines(b)
+
+	// lcs[i][j] is the length of the longest common subsequence of al[i:] and bl[j:].
+	lcs := make([][]int, len(al)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bl)+1)
+	}
+	for i := len(al) - 1; i >= 0; i-- {
+		for j := len(bl) - 1; j >= 0; j-- {
+			if al[i] == bl[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	// Each op is a line prefixed with ' ', '-' or '+'.
+	var ops []string
+	i, j := 0, 0
+	for i < len(al) || j < len(bl) {
+		switch {
+		case i < len(al) && j < len(bl) && al[i] == bl[j]:
+			ops = append(ops, " "+al[i])
+			i++
+			j++
+		case j == len(bl) || (i < len(al) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, "-"+al[i])
+			i++
+		default:
+			ops = append(ops, "+"+bl[j])
+			j++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+	aLine, bLine := 0, 0 // The lines of a and b before ops[k].
+	for k := 0; k < len(ops); {
+		if ops[k][0] == ' ' {
+			aLine++
+			bLine++
+			k++
+			continue
+		}
+		// A hunk starts diffContext lines before the change, and runs until there are more than
+		// 2*diffContext unchanged lines before the next one.
+		start := k - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := k
+		for unchanged := 0; end < len(ops) && unchanged <= 2*diffContext; end++ {
+			if ops[end][0] == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		for end > k && ops[end-1][0] == ' ' {
+			end--
+		}
+		if end += diffContext; end > len(ops) {
+			end = len(ops)
+		}
+
+		aStart, bStart := aLine-(k-start), bLine-(k-start)
+		var aLen, bLen int
+		var hunk strings.Builder
+		for _, op := range ops[start:end] {
+			if op[0] != '+' {
+				aLen++
+			}
+			if op[0] != '-' {
+				bLen++
+			}
+			hunk.WriteString(op)
+			if !strings.HasSuffix(op, "\n") {
+				hunk.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		// A hunk with no lines from a file starts at the line before it, as in diff -u.
+		if aLen > 0 {
+			aStart++
+		}
+		if bLen > 0 {
+			bStart++
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n%s", hunkRange(aStart, aLen), hunkRange(bStart, bLen), hunk.String())
+		for _, op := range ops[k:end] {
+			if op[0] != '+' {
+				aLine++
+			}
+			if op[0] != '-' {
+				bLine++
+			}
+		}
+		k = end
+	}
+	return out.String()
+}
+
+// fmtLine is a line of a today file reduced to what formatting must keep. (See fmtContent)
+type fmtLine struct {
+	n       int // The number of the line, starting at 1.
+	line    string
+	section string
+	text    string
+}
+
+// fmtNumberRe matches the number of a Startup item, which formatting may change.
+var fmtNumberRe = regexp.MustCompile(`^[0-9]+\.`)
+
+// fmtContent reduces the today file src to what formatting must not change: the indentation of
+// each line and its text apart from whitespace, and the blank lines within each section, which
+// become lines with empty text. Blank lines next to headers or at either end of the file are left
+// out, as are the numbers and indentation of Startup items, which formatting replaces.
+func fmtContent(src string) []fmtLine {
+	lines := strings.Split(src, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRightFunc(lines[i], unicode.IsSpace)
+	}
+	var content []fmtLine
+	blank := -1
+	for i, line := range lines {
+		if line == "" {
+			if blank < 0 {
+				blank = i
+			}
+			continue
+		}
+		section := today.Section(lines, i)
+		if blank >= 0 && section != "" && len(content) > 0 && content[len(content)-1].section == section {
+			content = append(content, fmtLine{n: blank + 1, section: section})
+		}
+		blank = -1
+
+		rest := strings.TrimLeftFunc(line, unicode.IsSpace)
+		indent := line[:len(line)-len(rest)]
+		if section == "Morning Start Up:" {
+			indent, rest = "", fmtNumberRe.ReplaceAllString(rest, "")
+		}
+		content = append(content, fmtLine{
+			n:       i + 1,
+			line:    line,
+			section: section,
+			text:    indent + strings.Join(strings.Fields(rest), ""),
+		})
+	}
+	return content
+}
+
+// checkFormatted returns an error naming the first line of src that formatted doesn't keep, if
+// formatting src as formatted would lose or change anything but whitespace.
+func checkFormatted(name, src, formatted string) error {
+	a, b := fmtContent(src), fmtContent(formatted)
+	for i, l := range a {
+		if i < len(b) && l.text == b[i].text {
+			continue
+		}
+		what := "the blank line"
+		if l.text != "" {
+			what = fmt.Sprintf("%q", l.line)
+		}
+		return fmt.Errorf("%s:%d: can't format without losing or changing %s", name, l.n, what)
+	}
+	if len(b) > len(a) {
+		return fmt.Errorf("%s: can't format without adding %q", name, b[len(a)].line)
+	}
+	return nil
+}
+
+// fmtSource returns the today file src as it is formatted: parsed, with its Startup items
+// renumbered, and written out again.
+func fmtSource(src string) (string, error) {
+	t, err := today.Parse(strings.NewReader(src))
+	if err != nil {
+		return "", err
+	}
+	t.Format()
+	var b strings.Builder
+	if err := t.Write(&b); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// fmtFile formats the today file name, or stdin if name is "-", and returns whether it was already
+// formatted. If check or diff is set, nothing is written: the name is listed if it isn't formatted,
+// or the changes are printed as a diff. Otherwise the file is rewritten, or stdin is written to
+// stdout. If formatting would change more than whitespace, because Parse doesn't keep something in
+// the file, fmtFile returns an error instead. (See checkFormatted)
+func fmtFile(name string, check, diff bool) (bool, error) {
+	var (
+		src []byte
+		err error
+	)
+	display := name
+	if name == "-" {
+		display = "<standard input>"
+		src, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		src, err = ioutil.ReadFile(name)
+	}
+	if err != nil {
+		return false, err
+	}
+	out, err := fmtSource(string(src))
+	if err != nil {
+		return false, fmt.Errorf("%s: %s", display, err)
+	}
+	formatted := string(src) == out
+	if !formatted {
+		if err := checkFormatted(display, string(src), out); err != nil {
+			return false, err
+		}
+	}
+
+	switch {
+	case check || diff:
+		if !formatted && check {
+			fmt.Println(display)
+		}
+		if !formatted && diff {
+			fmt.Print(unifiedDiff(display+".orig", display, string(src), out))
+		}
+	case name == "-":
+		_, err = io.WriteString(os.Stdout, out)
+	case !formatted:
+		err = writeFileAtomic(name, func(w io.Writer) error {
+			_, err := io.WriteString(w, out)
+			return err
+		})
+	}
+	return formatted, err
+}
+
+// fmtCmd normalizes the layout of today files without changing what they say. Unlike running today
+// with -i, it doesn't date statuses, name tasks, write Log entries or sort.
+func fmtCmd(dir string, args []string) error {
+	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
+	check := fs.Bool("check", false, "List the files that aren't formatted instead of rewriting them, and fail if there are any.")
+	diff := fs.Bool("diff", false, "Print a unified diff of the formatting changes instead of rewriting the files.")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: today fmt [-check] [-diff] [FILE ...]\n")
+		fmt.Fprintf(fs.Output(), "Formats the most recent today file, or the named files. A FILE of - formats stdin to stdout.\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	files := fs.Args()
+	if len(files) == 0 {
+		unlock, err := lockDir(dir)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		filedates, err := noteFiles(dir)
+		if err != nil {
+			return err
+		}
+		files = []string{path.Join(dir, filedates[0].name)}
+	}
+
+	unformatted := 0
+	for _, name := range files {
+		formatted, err := fmtFile(name, *check, *diff)
+		if err != nil {
+			return err
+		}
+		if !formatted {
+			unformatted++
+		}
+	}
+	if *check && unformatted > 0 {
+		return fmt.Errorf("%d of %d files not formatted", unformatted, len(files))
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// numbers returns the lines 1 to n, with those in replace replaced.
+func numbers(n int, replace map[int]string) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		line, ok := replace[i]
+		if !ok {
+			line = strconv.Itoa(i)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	// The expected diffs are the output of diff -u.
+	for _, c := range []struct {
+		name, a, b, diff string
+	}{
+		{"same", "x\ny\n", "x\ny\n", ""},
+		{
+			"two hunks",
+			numbers(20, nil),
+			numbers(20, map[int]string{2: "two", 18: "eighteen"}),
+			"--- a\n+++ b\n" +
+				"@@ -1,5 +1,5 @@\n 1\n-2\n+two\n 3\n 4\n 5\n" +
+				"@@ -15,6 +15,6 @@\n 15\n 16\n 17\n-18\n+eighteen\n 19\n 20\n",
+		},
+		{
+			"changes sharing context",
+			numbers(10, nil),
+			numbers(10, map[int]string{4: "four", 8: "eight"}),
+			"--- a\n+++ b\n@@ -1,10 +1,10 @@\n 1\n 2\n 3\n-4\n+four\n 5\n 6\n 7\n-8\n+eight\n 9\n 10\n",
+		},
+		{
+			"appended",
+			numbers(8, nil),
+			numbers(8, nil) + "new\n",
+			"--- a\n+++ b\n@@ -6,3 +6,4 @@\n 6\n 7\n 8\n+new\n",
+		},
+		{"one line", "x\n", "y\n", "--- a\n+++ b\n@@ -1 +1 @@\n-x\n+y\n"},
+		{"from empty", "", "y\n", "--- a\n+++ b\n@@ -0,0 +1 @@\n+y\n"},
+		{
+			"no newline at end",
+			"x\ny",
+			"x\nz\n",
+			"--- a\n+++ b\n@@ -1,2 +1,2 @@\n x\n-y\n\\ No newline at end of file\n+z\n",
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.diff, unifiedDiff("a", "b", c.a, c.b))
+		})
+	}
+}
+
+const fmtTestFile = `Morning Start Up:
+1. Catch up on slack [DONE - Jan  6, 2020]
+2. Check the calendar
+
+Notes:
+some note
+
+Log:
+9:00AM - Standup
+9:30AM - Planning
+
+TODO:
+JIRA-1 - Write it [READY]
+	a comment
+
+Fix the sink
+
+`
+
+func TestCheckFormatted(t *testing.T) {
+	for _, c := range []struct {
+		name, src, err string
+	}{
+		{"formatted", fmtTestFile, ""},
+		{
+			"whitespace",
+			strings.NewReplacer(
+				"2. Check the calendar", "  7.Check the calendar  ",
+				"JIRA-1 - Write it [READY]", "JIRA-1  -  Write it[ READY ]",
+				"Notes:\n", "\n\nNotes:\n",
+				"Fix the sink\n", "\n\nFix the sink",
+			).Replace(fmtTestFile),
+			"",
+		},
+		{"preamble", "a preamble line\n\n" + fmtTestFile, `x:1: can't format without losing or changing "a preamble line"`},
+		{
+			"blank line in the Log",
+			strings.Replace(fmtTestFile, "Standup\n", "Standup\n\n", 1),
+			"x:10: can't format without losing or changing the blank line",
+		},
+		{
+			"blank line in the Startup section",
+			strings.Replace(fmtTestFile, "2020]\n", "2020]\n\n", 1),
+			"x:3: can't format without losing or changing the blank line",
+		},
+		{
+			"comment indented more",
+			strings.Replace(fmtTestFile, "\ta comment", "\t  a comment", 1),
+			`x:14: can't format without losing or changing "\t  a comment"`,
+		},
+		{
+			"comment indented with spaces",
+			strings.Replace(fmtTestFile, "\ta comment", "    a comment", 1),
+			`x:14: can't format without losing or changing "    a comment"`,
+		},
+		{
+			"header with more text",
+			strings.Replace(fmtTestFile, "Log:", "Log: for today", 1),
+			`x:8: can't format without losing or changing "Log: for today"`,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			formatted, err := fmtSource(c.src)
+			if !assert.NoError(t, err) {
+				return
+			}
+			err = checkFormatted("x", c.src, formatted)
+			if c.err == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, fmtTestFile, formatted)
+			} else {
+				assert.EqualError(t, err, c.err)
+			}
+		})
+	}
+}
+
+func TestFmtFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "today-fmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, text string) string {
+		name = path.Join(dir, name)
+		if err := ioutil.WriteFile(name, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return name
+	}
+	read := func(name string) string {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	name := write("formatted.txt", fmtTestFile)
+	formatted, err := fmtFile(name, false, false)
+	assert.NoError(t, err)
+	assert.True(t, formatted)
+	assert.Equal(t, fmtTestFile, read(name))
+
+	unformatted := strings.Replace(fmtTestFile, "2. Check the calendar", "Check the calendar   ", 1)
+	name = write("unformatted.txt", unformatted)
+	formatted, err = fmtFile(name, true, false)
+	assert.NoError(t, err)
+	assert.False(t, formatted)
+	assert.Equal(t, unformatted, read(name)) // -check doesn't write.
+	formatted, err = fmtFile(name, false, false)
+	assert.NoError(t, err)
+	assert.False(t, formatted)
+	assert.Equal(t, fmtTestFile, read(name))
+
+	lossy := "a preamble line\n" + fmtTestFile
+	name = write("lossy.txt", lossy)
+	_, err = fmtFile(name, false, false)
+	assert.EqualError(t, err, name+`:1: can't format without losing or changing "a preamble line"`)
+	assert.Equal(t, lossy, read(name))
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/knusbaum/today"
+)
+
+// statusText formats a status without its date, the way it appears inside the brackets of a
+// today file.
+func statusText(s today.Status) string {
+	if s.Comment == "" {
+		return s.Name
+	}
+	if s.Name == "" {
+		return s.Comment
+	}
+	return s.Name + " - " + s.Comment
+}
+
+// historyCmd prints a diff-style timeline of a single task across every today file.
+func historyCmd(dir string, args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: today history TASK-NAME\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	name := fs.Arg(0)
+
+	days, err := loadDays(dir)
+	if err != nil {
+		return err
+	}
+	events := today.TaskHistory(name, days)
+	if len(events) == 0 {
+		return fmt.Errorf("no task named %s", name)
+	}
+
+	fmt.Printf("%s - %s\n", name, events[len(events)-1].Description)
+	for _, line := range historyLines(events) {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// historyLines formats a task's events as a diff-style timeline, one line per event.
+func historyLines(events []today.TaskEvent) []string {
+	var lines []string
+	for _, e := range events {
+		date := e.Date.Format("Jan _2, 2006")
+		switch e.Kind {
+		case today.Created:
+			lines = append(lines, fmt.Sprintf("%s + created [%s]", date, statusText(e.Status)))
+		case today.StatusChanged:
+			lines = append(lines, fmt.Sprintf("%s ~ [%s] -> [%s]", date, statusText(e.Previous), statusText(e.Status)))
+		case today.Completed:
+			lines = append(lines, fmt.Sprintf("%s * [%s]", date, statusText(e.Status)))
+		case today.CommentAdded:
+			lines = append(lines, fmt.Sprintf("%s + \t%s", date, e.Comment))
+		case today.CommentRemoved:
+			lines = append(lines, fmt.Sprintf("%s - \t%s", date, e.Comment))
+		case today.Removed:
+			lines = append(lines, fmt.Sprintf("%s - removed [%s]", date, statusText(e.Previous)))
+		}
+	}
+	return lines
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/knusbaum/today"
+)
+
+// importers read tasks from another format, keyed by the name given to -format.
+var importers = map[string]func(r io.Reader) ([]*today.Task, error){
+	"md":          today.ParseMarkdownTasks,
+	"org":         today.ParseOrgTasks,
+	"taskwarrior": today.ParseTaskwarrior,
+	"todotxt":     today.ParseTodoTxt,
+}
+
+// importCmd merges the tasks from a file in another format into the current today file, then
+// updates, sorts and saves it. Status changes made in the other format are dated and logged.
+// (See today.TaskList.Merge)
+func importCmd(dir string, args []string) error {
+	var names []string
+	for name := range importers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", "md", "The format to import from. One of: "+strings.Join(names, ", "))
+	fs.StringVar(format, "from", "md", "Same as -format.")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: today import [-format FORMAT] FILE\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	parse, ok := importers[*format]
+	if !ok {
+		return fmt.Errorf("unknown import format %q", *format)
+	}
+	in := os.Stdin
+	if fs.Arg(0) != "-" {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	tasks, err := parse(in)
+	if err != nil {
+		return err
+	}
+
+	return updateToday(dir, func(t *today.Today) error {
+		t.Tasks.Merge(tasks)
+		t.Update()
+		t.Sort()
+		return nil
+	})
+}
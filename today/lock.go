@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/knusbaum/today"
+)
+
+// lockFile is created in the today directory while a today process is changing files there.
+const lockFile = ".lock"
+
+const (
+	// lockTimeout is how long to wait for another process to release the lock.
+	lockTimeout = 10 * time.Second
+	// lockStale is how old a lock file must be before it is assumed to have been left behind by a
+	// process that died while holding it. Locks are only held for as long as a read and write take.
+	lockStale = 30 * time.Second
+)
+
+// dirMu keeps goroutines in this process, such as the handlers of today serve, from racing each
+// other for the lock file.
+var dirMu sync.Mutex
+
+// lockDir takes the lock on the today directory dir, waiting for other processes to release it.
+// The returned function releases it.
+func lockDir(dir string) (func(), error) {
+	dirMu.Lock()
+	name := path.Join(dir, lockFile)
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return func() {
+				os.Remove(name)
+				dirMu.Unlock()
+			}, nil
+		}
+		if !os.IsExist(err) {
+			dirMu.Unlock()
+			return nil, err
+		}
+		if fi, err := os.Stat(name); err == nil && time.Since(fi.ModTime()) > lockStale {
+			os.Remove(name)
+			continue
+		}
+		if time.Now().After(deadline) {
+			dirMu.Unlock()
+			return nil, fmt.Errorf("timed out waiting for %s", name)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// writeFileAtomic writes a file by writing a temporary file next to it and renaming it into place,
+// so that readers never see a partly written file.
+func writeFileAtomic(name string, write func(w io.Writer) error) error {
+	f, err := ioutil.TempFile(path.Dir(name), ".today-")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	err = write(f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp, name)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// writeToday backs up the current day's today file in dir and atomically replaces it with what
+// write writes. The caller should hold the lock on dir. (See lockDir)
+func writeToday(dir string, write func(w io.Writer) error) error {
+	name := path.Join(dir, time.Now().Format(noteFormat))
+	copyFileContents(name, path.Join(dir, ".backup"))
+	return writeFileAtomic(name, write)
+}
+
+// updateToday loads the current day's today file in dir, generating it if needed, applies change to
+// it and saves it, all while holding the lock on dir so that no other change is lost. If change
+// returns an error, nothing is saved.
+func updateToday(dir string, change func(t *today.Today) error) error {
+	unlock, err := lockDir(dir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	t, err := loadToday(dir)
+	if err != nil {
+		return err
+	}
+	if err := change(t); err != nil {
+		return err
+	}
+	return writeToday(dir, t.Write)
+}
+
+// rolloverToday generates the current day's today file in dir from the previous one if it doesn't
+// exist yet, and returns whether it did.
+func rolloverToday(dir string) (bool, error) {
+	unlock, err := lockDir(dir)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	exists, err := todayExists(dir)
+	if err != nil || exists {
+		return false, err
+	}
+	return true, generateToday(dir)
+}
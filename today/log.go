@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/knusbaum/today"
+)
+
+// logCmd appends a timestamped entry to the Log of the current today file. With no message, it
+// prints the entries of the current Log instead.
+func logCmd(dir string, args []string) error {
+	fs := flag.NewFlagSet("log", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: today log [message...]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		t, date, err := readTodayDate(dir)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		for _, e := range t.Log.Entries(date) {
+			when := ""
+			if !e.Time.IsZero() {
+				when = e.Time.Format(time.Kitchen)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", when, e.Kind, e.Task, e.Message)
+		}
+		return w.Flush()
+	}
+
+	message := strings.Join(fs.Args(), " ")
+	return updateToday(dir, func(t *today.Today) error {
+		t.Log.Append(today.NewLogEntry(message))
+		return nil
+	})
+}
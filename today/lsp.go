@@ -0,0 +1,452 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/knusbaum/today"
+	"github.com/knusbaum/today/jsonrpc"
+)
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspTextDocument struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version,omitempty"`
+	Text    string `json:"text,omitempty"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type lspTextEdit struct {
+	Range   lspRange `json:"range"`
+	NewText string   `json:"newText"`
+}
+
+type lspCompletionItem struct {
+	Label    string      `json:"label"`
+	Kind     int         `json:"kind"`
+	Detail   string      `json:"detail,omitempty"`
+	TextEdit lspTextEdit `json:"textEdit"`
+}
+
+type lspMarkup struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type lspHover struct {
+	Contents lspMarkup `json:"contents"`
+	Range    lspRange  `json:"range"`
+}
+
+type lspCodeAction struct {
+	Title string `json:"title"`
+	Kind  string `json:"kind"`
+	Edit  struct {
+		Changes map[string][]lspTextEdit `json:"changes"`
+	} `json:"edit"`
+}
+
+// The parameters of the requests and notifications the server handles. Each has only the fields
+// that are used.
+type lspParams struct {
+	TextDocument   lspTextDocument `json:"textDocument"`
+	Position       lspPosition     `json:"position"`
+	Range          lspRange        `json:"range"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+const (
+	lspSeverityError   = 1
+	lspSeverityWarning = 2
+
+	lspCompletionEnumMember = 20
+)
+
+var (
+	lspTaskNameRe = regexp.MustCompile(`[A-Z]+-[0-9]+`)
+	// lspStatusRe matches the status at the end of a task or Startup item.
+	lspStatusRe = regexp.MustCompile(`\[[^][]*\][[:space:]]*$`)
+)
+
+// utf16Len returns the length of s in UTF-16 code units, which LSP positions are counted in.
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// byteOffset returns the byte offset in line of the UTF-16 offset col.
+func byteOffset(line string, col int) int {
+	n := 0
+	for i, r := range line {
+		if n >= col {
+			return i
+		}
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return len(line)
+}
+
+// lspLines splits a document into lines, without their line endings.
+func lspLines(text string) []string {
+	lines := strings.Split(text, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSuffix(lines[i], "\r")
+	}
+	return lines
+}
+
+// lspServer serves the Language Server Protocol for today files.
+type lspServer struct {
+	dir      string
+	conn     *jsonrpc.Conn
+	docs     map[string]string
+	days     map[string]*lspDays
+	shutdown bool
+}
+
+// lspDays holds the parsed today files of a directory, for hovers.
+type lspDays struct {
+	// key identifies the versions of the files the days were parsed from. (See daysKey)
+	key  string
+	days []today.Day
+}
+
+// daysKey returns a string that changes whenever a today file in dir is added, removed or
+// rewritten.
+func daysKey(dir string) (string, error) {
+	filedates, err := noteFiles(dir)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, fd := range filedates {
+		fi, err := os.Stat(path.Join(dir, fd.name))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s %d %d\n", fd.name, fi.Size(), fi.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
+
+// loadDays returns the days in dir, as loadDays does, parsing the files again only when they have
+// changed since the last call.
+func (s *lspServer) loadDays(dir string) ([]today.Day, error) {
+	key, err := daysKey(dir)
+	if err != nil {
+		return nil, err
+	}
+	if d, ok := s.days[dir]; ok && d.key == key {
+		return d.days, nil
+	}
+	days, err := loadDays(dir)
+	if err != nil {
+		return nil, err
+	}
+	s.days[dir] = &lspDays{key: key, days: days}
+	return days, nil
+}
+
+// dirFor returns the today directory for the document at uri: the document's directory if it is a
+// today file, and the directory given with -d otherwise.
+func (s *lspServer) dirFor(uri string) string {
+	u, err := url.Parse(uri)
+	if err == nil && u.Scheme == "file" {
+		if _, err := time.Parse(noteFormat, path.Base(u.Path)); err == nil {
+			return path.Dir(u.Path)
+		}
+	}
+	return s.dir
+}
+
+func (s *lspServer) publishDiagnostics(uri string) {
+	diagnostics := []lspDiagnostic{}
+	if text, ok := s.docs[uri]; ok {
+		lines := lspLines(text)
+		problems, _ := today.Check(strings.NewReader(text))
+		for _, p := range problems {
+			line := ""
+			if p.Line-1 < len(lines) {
+				line = lines[p.Line-1]
+			}
+			d := lspDiagnostic{
+				Range: lspRange{
+					Start: lspPosition{p.Line - 1, utf16Len(line[:p.Start])},
+					End:   lspPosition{p.Line - 1, utf16Len(line[:p.End])},
+				},
+				Severity: lspSeverityError,
+				Source:   "today",
+				Message:  p.Message,
+			}
+			if p.Warning {
+				d.Severity = lspSeverityWarning
+			}
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	s.conn.Notify("textDocument/publishDiagnostics", map[string]interface{}{
+		"uri":         uri,
+		"diagnostics": diagnostics,
+	})
+}
+
+// completion completes status names inside the square brackets of a status.
+func (s *lspServer) completion(p *lspParams) []lspCompletionItem {
+	items := []lspCompletionItem{}
+	lines := lspLines(s.docs[p.TextDocument.URI])
+	if p.Position.Line < 0 || p.Position.Line >= len(lines) {
+		return items
+	}
+	line := lines[p.Position.Line]
+	prefix := line[:byteOffset(line, p.Position.Character)]
+	open := strings.LastIndex(prefix, "[")
+	if open < 0 || strings.ContainsAny(prefix[open:], "]-") {
+		return items
+	}
+	replace := lspRange{
+		Start: lspPosition{p.Position.Line, utf16Len(prefix[:open+1])},
+		End:   p.Position,
+	}
+
+	names := today.StatusNames()
+	known := make(map[string]bool)
+	for _, name := range names {
+		known[name] = true
+	}
+	if t, err := today.Parse(strings.NewReader(s.docs[p.TextDocument.URI])); err == nil {
+		var seen []today.Status
+		for _, item := range t.Startup {
+			seen = append(seen, item.Status)
+		}
+		for _, task := range t.Tasks.Tasks {
+			seen = append(seen, task.Status)
+		}
+		for _, status := range seen {
+			if status.Name != "" && !known[status.Name] {
+				known[status.Name] = true
+				names = append(names, status.Name)
+			}
+		}
+	}
+	for _, name := range names {
+		// The status is dated tomorrow so that its own bucket is shown, rather than the one it
+		// would be resurfaced to.
+		status := today.Status{Name: name, Date: time.Now().AddDate(0, 0, 1)}
+		items = append(items, lspCompletionItem{
+			Label:    name,
+			Kind:     lspCompletionEnumMember,
+			Detail:   bucketName(status.Priority()),
+			TextEdit: lspTextEdit{Range: replace, NewText: name},
+		})
+	}
+	return items
+}
+
+// hover shows the history of the task named under the cursor.
+func (s *lspServer) hover(p *lspParams) *lspHover {
+	lines := lspLines(s.docs[p.TextDocument.URI])
+	if p.Position.Line < 0 || p.Position.Line >= len(lines) {
+		return nil
+	}
+	line := lines[p.Position.Line]
+	at := byteOffset(line, p.Position.Character)
+	for _, m := range lspTaskNameRe.FindAllStringIndex(line, -1) {
+		if at < m[0] || at > m[1] {
+			continue
+		}
+		name := line[m[0]:m[1]]
+		days, err := s.loadDays(s.dirFor(p.TextDocument.URI))
+		if err != nil {
+			return nil
+		}
+		events := today.TaskHistory(name, days)
+		if len(events) == 0 {
+			return nil
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "**%s** - %s\n\n```\n", name, events[len(events)-1].Description)
+		for _, l := range historyLines(events) {
+			b.WriteString(l + "\n")
+		}
+		b.WriteString("```\n")
+		return &lspHover{
+			Contents: lspMarkup{Kind: "markdown", Value: b.String()},
+			Range: lspRange{
+				Start: lspPosition{p.Position.Line, utf16Len(line[:m[0]])},
+				End:   lspPosition{p.Position.Line, utf16Len(line[:m[1]])},
+			},
+		}
+	}
+	return nil
+}
+
+// setStatus returns an edit that gives the task or Startup item on line n the status text.
+func setStatus(line string, n int, status string) lspTextEdit {
+	end := len(strings.TrimRightFunc(line, func(r rune) bool { return r == ' ' || r == '\t' }))
+	start, text := end, " ["+status+"]"
+	if m := lspStatusRe.FindStringIndex(line); m != nil {
+		start, end, text = m[0], m[0]+strings.Index(line[m[0]:], "]")+1, "["+status+"]"
+	}
+	return lspTextEdit{
+		Range: lspRange{
+			Start: lspPosition{n, utf16Len(line[:start])},
+			End:   lspPosition{n, utf16Len(line[:end])},
+		},
+		NewText: text,
+	}
+}
+
+// codeActions offers to mark the task or Startup item on the first line of the range DONE, to hold
+// the task until a later date, and to sort the file.
+func (s *lspServer) codeActions(p *lspParams) []lspCodeAction {
+	actions := []lspCodeAction{}
+	uri := p.TextDocument.URI
+	text := s.docs[uri]
+	lines := lspLines(text)
+	action := func(title, kind string, edits ...lspTextEdit) {
+		a := lspCodeAction{Title: title, Kind: kind}
+		a.Edit.Changes = map[string][]lspTextEdit{uri: edits}
+		actions = append(actions, a)
+	}
+
+	if n := p.Range.Start.Line; n >= 0 && n < len(lines) && strings.TrimSpace(lines[n]) != "" {
+		line := lines[n]
+		section := today.Section(lines, n)
+		isTask := section == "TODO:" && !strings.HasPrefix(line, "\t")
+		if isTask || section == "Morning Start Up:" {
+			// The DONE status is left undated, so that the next run of today dates it and logs it.
+			action("Mark DONE", "quickfix", setStatus(line, n, "DONE"))
+		}
+		if isTask {
+			now := time.Now()
+			monday := int(time.Monday-now.Weekday()+7) % 7
+			if monday == 0 {
+				monday = 7
+			}
+			holds := []struct {
+				title string
+				days  int
+			}{
+				{"Hold until tomorrow", 1},
+				{"Hold until Monday", monday},
+				{"Hold for a week", 7},
+			}
+			for _, h := range holds {
+				date := now.AddDate(0, 0, h.days).Format("Jan _2, 2006")
+				action(h.title+" ("+date+")", "quickfix", setStatus(line, n, "HOLD - "+date))
+			}
+		}
+	}
+
+	// Sorting rewrites the file the way formatting does, so it's only offered when formatting
+	// wouldn't lose anything. (See checkFormatted)
+	if formatted, err := fmtSource(text); err != nil || checkFormatted("", text, formatted) != nil {
+		return actions
+	}
+	if t, err := today.Parse(strings.NewReader(text)); err == nil {
+		t.Sort()
+		var b bytes.Buffer
+		if err := t.Write(&b); err == nil && b.String() != text {
+			last := len(lines) - 1
+			action("Sort file", "source", lspTextEdit{
+				Range:   lspRange{End: lspPosition{last, utf16Len(lines[last])}},
+				NewText: b.String(),
+			})
+		}
+	}
+	return actions
+}
+
+func (s *lspServer) handle(method string, params json.RawMessage) (interface{}, error) {
+	var p lspParams
+	if err := jsonrpc.Params(params, &p); err != nil {
+		return nil, err
+	}
+	switch method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":   1, // Full
+				"completionProvider": map[string]interface{}{"triggerCharacters": []string{"["}},
+				"hoverProvider":      true,
+				"codeActionProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "today"},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "exit":
+		if s.shutdown {
+			os.Exit(0)
+		}
+		os.Exit(1)
+	case "textDocument/didOpen":
+		s.docs[p.TextDocument.URI] = p.TextDocument.Text
+		s.publishDiagnostics(p.TextDocument.URI)
+	case "textDocument/didChange":
+		if len(p.ContentChanges) > 0 {
+			s.docs[p.TextDocument.URI] = p.ContentChanges[len(p.ContentChanges)-1].Text
+		}
+		s.publishDiagnostics(p.TextDocument.URI)
+	case "textDocument/didClose":
+		delete(s.docs, p.TextDocument.URI)
+		s.publishDiagnostics(p.TextDocument.URI)
+	case "textDocument/completion":
+		return s.completion(&p), nil
+	case "textDocument/hover":
+		return s.hover(&p), nil
+	case "textDocument/codeAction":
+		return s.codeActions(&p), nil
+	case "initialized", "textDocument/didSave", "$/cancelRequest", "$/setTrace":
+	default:
+		return nil, jsonrpc.Errorf(jsonrpc.MethodNotFound, "no method %s", method)
+	}
+	return nil, nil
+}
+
+// lspCmd serves the Language Server Protocol for today files over stdin and stdout.
+func lspCmd(dir string, args []string) error {
+	fs := flag.NewFlagSet("lsp", flag.ExitOnError)
+	fs.Parse(args)
+
+	s := &lspServer{dir: dir, docs: make(map[string]string), days: make(map[string]*lspDays)}
+	s.conn = jsonrpc.NewConn(os.Stdin, os.Stdout)
+	return s.conn.Serve(s.handle)
+}
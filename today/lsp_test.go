@@ -0,0 +1,141 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const lspTestDoc = `Morning Start Up:
+1. Check the calendar [BLOCKED]
+
+Notes:
+
+Log:
+
+TODO:
+JIRA-1 - Write the report [RE
+	a comment
+`
+
+func newTestLSPServer(dir string) *lspServer {
+	return &lspServer{dir: dir, docs: make(map[string]string), days: make(map[string]*lspDays)}
+}
+
+func TestLSPCompletion(t *testing.T) {
+	s := newTestLSPServer("")
+	s.docs["file:///doc"] = lspTestDoc
+
+	p := &lspParams{}
+	p.TextDocument.URI = "file:///doc"
+	p.Position = lspPosition{8, len("JIRA-1 - Write the report [RE")}
+	items := s.completion(p)
+	var labels []string
+	for _, item := range items {
+		labels = append(labels, item.Label)
+		assert.Equal(t, lspRange{Start: lspPosition{8, len("JIRA-1 - Write the report [")}, End: p.Position}, item.TextEdit.Range)
+	}
+	assert.Contains(t, labels, "READY")
+	assert.Contains(t, labels, "BLOCKED") // Statuses used in the file are offered too.
+
+	// Outside a status there is nothing to complete.
+	p.Position = lspPosition{8, 3}
+	assert.Empty(t, s.completion(p))
+	for _, line := range []int{-1, 100} {
+		p.Position = lspPosition{line, 0}
+		assert.Empty(t, s.completion(p))
+	}
+}
+
+func TestLSPCodeActions(t *testing.T) {
+	s := newTestLSPServer("")
+	s.docs["file:///doc"] = lspTestDoc
+
+	titles := func(line int) []string {
+		p := &lspParams{}
+		p.TextDocument.URI = "file:///doc"
+		p.Range.Start.Line = line
+		var titles []string
+		for _, a := range s.codeActions(p) {
+			if a.Kind == "quickfix" {
+				titles = append(titles, strings.SplitN(a.Title, " (", 2)[0])
+			}
+		}
+		return titles
+	}
+	assert.Equal(t, []string{"Mark DONE"}, titles(1))
+	assert.Equal(t, []string{"Mark DONE", "Hold until tomorrow", "Hold until Monday", "Hold for a week"}, titles(8))
+	assert.Empty(t, titles(0))  // Morning Start Up:
+	assert.Empty(t, titles(7))  // TODO:
+	assert.Empty(t, titles(9))  // A comment.
+	assert.Empty(t, titles(-1)) // Out of range.
+	assert.Empty(t, titles(100))
+
+	sortable := func(doc string) bool {
+		s.docs["file:///doc"] = doc
+		p := &lspParams{}
+		p.TextDocument.URI = "file:///doc"
+		for _, a := range s.codeActions(p) {
+			if a.Title == "Sort file" {
+				return true
+			}
+		}
+		return false
+	}
+	unsorted := strings.Replace(lspTestDoc, "TODO:\n", "TODO:\nJIRA-2 - Done already [DONE - Jan  6, 2020]\n", 1)
+	assert.True(t, sortable(unsorted))
+	// Sorting would drop the line before the first section.
+	assert.False(t, sortable("a preamble line\n"+unsorted))
+}
+
+func TestLSPHover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "today-lsp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(date time.Time, task string) {
+		text := "Morning Start Up:\n\nNotes:\n\nLog:\n\nTODO:\n" + task + "\n"
+		if err := ioutil.WriteFile(path.Join(dir, date.Format(noteFormat)), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	day1 := time.Date(2020, time.January, 6, 0, 0, 0, 0, time.Local)
+	day2 := day1.AddDate(0, 0, 1)
+	write(day1, "JIRA-1 - Write the report [READY - Jan 6, 2020]")
+	write(day2, "JIRA-1 - Write the report [IN PROGRESS - Jan 7, 2020]")
+
+	s := newTestLSPServer("")
+	uri := "file://" + path.Join(dir, day2.Format(noteFormat))
+	s.docs[uri] = "TODO:\nsee JIRA-1 and JIRA-2\n"
+	p := &lspParams{}
+	p.TextDocument.URI = uri
+	p.Position = lspPosition{1, len("see JIRA-")}
+	h := s.hover(p)
+	if assert.NotNil(t, h) {
+		assert.Contains(t, h.Contents.Value, "**JIRA-1** - Write the report")
+		assert.Contains(t, h.Contents.Value, "IN PROGRESS")
+		assert.NotContains(t, h.Contents.Value, "DONE")
+		assert.Equal(t, lspRange{Start: lspPosition{1, 4}, End: lspPosition{1, 10}}, h.Range)
+	}
+
+	// The days are parsed again once a file changes.
+	write(day2, "JIRA-1 - Write the report, at last [DONE - Jan 7, 2020]")
+	h = s.hover(p)
+	if assert.NotNil(t, h) {
+		assert.Contains(t, h.Contents.Value, "**JIRA-1** - Write the report, at last")
+		assert.Contains(t, h.Contents.Value, "DONE")
+	}
+
+	p.Position = lspPosition{1, len("see JIRA-1 and JIRA-")}
+	assert.Nil(t, s.hover(p)) // No history.
+	p.Position = lspPosition{1, 1}
+	assert.Nil(t, s.hover(p))
+	p.Position = lspPosition{-1, 0}
+	assert.Nil(t, s.hover(p))
+}
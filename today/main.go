@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bufio"
 	"flag"
 	"fmt"
 	"io"
@@ -8,6 +9,7 @@ import (
 	"os"
 	"path"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/knusbaum/today"
@@ -15,10 +17,40 @@ import (
 
 const (
 	noteFormat = "note.2006.Jan.02.txt"
+	// startupFile holds the full Startup definition once any Startup item has a schedule. Today
+	// files only contain the items that apply on their day.
+	startupFile = "startup.txt"
 )
 
+// startupTags are the tags in effect for scheduled Startup items. (See today.ListItem)
+var startupTags []string
+
 var errNoTodayFiles error = fmt.Errorf("no existing today files")
 
+// commands maps subcommand names to their implementations. Each command is given the today
+// directory and the arguments following the command name. When no command is named, today
+// updates and sorts the current today file.
+var commands = map[string]func(dir string, args []string) error{
+	"9p":              ninepCmd,
+	"daemon":          daemonCmd,
+	"export":          exportCmd,
+	"fmt":             fmtCmd,
+	"history":         historyCmd,
+	"import":          importCmd,
+	"import-calendar": importCalendarCmd,
+	"log":             logCmd,
+	"lsp":             lspCmd,
+	"note":            noteCmd,
+	"stats":           statsCmd,
+	"serve":           serveCmd,
+	"show":            showCmd,
+	"site":            siteCmd,
+	"sort":            sortCmd,
+	"startup":         startupCmd,
+	"tui":             tuiCmd,
+	"web":             webCmd,
+}
+
 // copyFileContents copies the contents of the file named src to the file named
 // by dst. The file will be created if it does not already exist. If the
 // destination file exists, all it's contents will be replaced by the contents
@@ -68,34 +100,67 @@ func (a byDate) Len() int           { return len(a) }
 func (a byDate) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a byDate) Less(i, j int) bool { return a[i].date.After(a[j].date) }
 
-func openMostRecent(dir string) (*os.File, error) {
+// noteFiles returns the today files in dir, most recent first.
+func noteFiles(dir string) ([]fileDate, error) {
 	d, err := os.Open(dir)
 	if err != nil {
 		return nil, err
 	}
+	defer d.Close()
 	files, err := d.Readdirnames(0)
 	if err != nil {
 		return nil, err
 	}
-	if len(files) == 0 {
-		return nil, errNoTodayFiles
-	}
 
 	filedates := make([]fileDate, 0, len(files))
 	for i := range files {
-		date, err := time.Parse(noteFormat, files[i])
+		date, err := time.ParseInLocation(noteFormat, files[i], time.Local)
 		if err == nil {
 			filedates = append(filedates, fileDate{files[i], date})
 		}
 	}
+	if len(filedates) == 0 {
+		return nil, errNoTodayFiles
+	}
 
 	sort.Sort(byDate(filedates))
+	return filedates, nil
+}
+
+// openMostRecent opens the most recent today file in dir, and returns its date.
+func openMostRecent(dir string) (*os.File, time.Time, error) {
+	filedates, err := noteFiles(dir)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
 
 	f, err := os.Open(path.Join(dir, filedates[0].name))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return f, filedates[0].date, nil
+}
+
+// loadDays parses every today file in dir and returns them oldest first.
+func loadDays(dir string) ([]today.Day, error) {
+	filedates, err := noteFiles(dir)
 	if err != nil {
 		return nil, err
 	}
-	return f, nil
+	days := make([]today.Day, 0, len(filedates))
+	for i := len(filedates) - 1; i >= 0; i-- {
+		f, err := os.Open(path.Join(dir, filedates[i].name))
+		if err != nil {
+			return nil, err
+		}
+		t, err := today.Parse(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %s", filedates[i].name, err)
+		}
+		days = append(days, today.Day{Date: filedates[i].date, Today: t})
+	}
+	return days, nil
 }
 
 func openReadToday(dir string) (*os.File, error) {
@@ -103,26 +168,113 @@ func openReadToday(dir string) (*os.File, error) {
 	return os.Open(name)
 }
 
-func openWriteToday(dir string) (*os.File, error) {
-	name := path.Join(dir, time.Now().Format(noteFormat))
-	copyFileContents(name, path.Join(dir, ".backup"))
-	return os.Create(name)
+// loadToday parses the current day's today file in dir, generating it first if it does not exist.
+func loadToday(dir string) (*today.Today, error) {
+	exists, err := todayExists(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := generateToday(dir); err != nil {
+			return nil, err
+		}
+	}
+	f, err := openReadToday(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return today.Parse(f)
+}
+
+// readToday parses the current day's today file in dir without generating or changing anything. If
+// there is no today file for the current day yet, the most recent one is read instead.
+func readToday(dir string) (*today.Today, error) {
+	t, _, err := readTodayDate(dir)
+	return t, err
+}
+
+// readTodayDate is like readToday, but also returns the date of the today file it read, which is
+// the date of the times in its Log.
+func readTodayDate(dir string) (*today.Today, time.Time, error) {
+	exists, err := todayExists(dir)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var (
+		f    *os.File
+		date time.Time
+	)
+	if exists {
+		y, m, d := time.Now().Date()
+		date = time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+		f, err = openReadToday(dir)
+	} else {
+		f, date, err = openMostRecent(dir)
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer f.Close()
+	t, err := today.Parse(f)
+	return t, date, err
+}
+
+// readStartup reads the Startup definition from dir. It returns nil if there isn't one.
+func readStartup(dir string) (today.List, error) {
+	f, err := os.Open(path.Join(dir, startupFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return today.ParseList(f), nil
+}
+
+func writeStartup(dir string, l today.List) error {
+	return writeFileAtomic(path.Join(dir, startupFile), func(f io.Writer) error {
+		w := bufio.NewWriter(f)
+		if err := l.Write(w); err != nil {
+			return err
+		}
+		return w.Flush()
+	})
+}
+
+// rolloverStartup returns the Startup section for a new today file, given the Startup section of
+// the previous one. Once any item has a schedule, the full definition is kept in startupFile, and
+// edits made to the previous day's Startup are merged into it.
+func rolloverStartup(dir string, prev today.List, prevDate time.Time) (today.List, error) {
+	def, err := readStartup(dir)
+	if err != nil {
+		return nil, err
+	}
+	if def == nil {
+		if !prev.Scheduled() {
+			return prev, nil
+		}
+	}
+	def = def.Merge(prev, prevDate)
+	if err := writeStartup(dir, def); err != nil {
+		return nil, err
+	}
+	return def.For(time.Now(), startupTags...), nil
 }
 
 func generateToday(dir string) error {
-	f, err := openMostRecent(dir)
+	filedates, err := noteFiles(dir)
 	if err != nil {
 		if err == errNoTodayFiles {
-			out, err := openWriteToday(dir)
-			if err != nil {
-				return err
-			}
-			defer out.Close()
 			var t today.Today
-			return t.Write(out)
+			return writeToday(dir, t.Write)
 		}
 		return err
 	}
+	f, err := os.Open(path.Join(dir, filedates[0].name))
+	if err != nil {
+		return err
+	}
 	defer f.Close()
 
 	t, err := today.Parse(f)
@@ -131,14 +283,15 @@ func generateToday(dir string) error {
 	}
 	t.Update()
 	t.Sort()
+	if err := recordStartup(dir, t.Startup, filedates[0].date); err != nil {
+		return err
+	}
 	t.Clear()
-
-	out, err := openWriteToday(dir)
+	t.Startup, err = rolloverStartup(dir, t.Startup, filedates[0].date)
 	if err != nil {
 		return err
 	}
-	defer out.Close()
-	return t.Write(out)
+	return writeToday(dir, t.Write)
 }
 
 func main() {
@@ -152,64 +305,61 @@ func main() {
 	var sort = flag.Bool("s", true, "Sort the todo entries according to priority.")
 	var update = flag.Bool("u", true, "Update the dates for the todo entries.")
 	var clear = flag.Bool("c", false, "Clear the DONE tasks. By default, this only happens when generating the today file.")
+	var tags = flag.String("tags", os.Getenv("TODAY_TAGS"), "Comma-separated tags in effect for scheduled Startup items, such as oncall. Defaults to $TODAY_TAGS.")
 
 	flag.Parse()
 
-	var (
-		in  io.Reader
-		out io.Writer
-	)
-
-	if *pipe {
-		in = os.Stdin
-	} else {
-		exists, err := todayExists(*dir)
-		if err != nil {
-			log.Fatalf("Failed to read todayfile: %s", err)
-		}
-		if exists {
-			f, err := openReadToday(*dir)
-			if err != nil {
-				log.Fatalf("Failed to read todayfile: %s", err)
-			}
-			defer f.Close()
-			in = f
-		} else {
-			err = generateToday(*dir)
-			if err != nil {
-				log.Fatalf("Failed to generate todayfile: %s", err)
-			}
-			return
+	for _, tag := range strings.Split(*tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			startupTags = append(startupTags, tag)
 		}
 	}
 
-	t, err := today.Parse(in)
-	if err != nil {
-		log.Fatalf("Failed to parse today: %s", err)
+	if flag.NArg() > 0 {
+		name := flag.Arg(0)
+		cmd, ok := commands[name]
+		if !ok {
+			log.Fatalf("Unknown command %q", name)
+		}
+		if err := cmd(*dir, flag.Args()[1:]); err != nil {
+			log.Fatalf("%s: %s", name, err)
+		}
+		return
 	}
 
-	if *update {
-		t.Update()
-	}
-	if *sort {
-		t.Sort()
-	}
-	if *clear {
-		t.Clear()
+	apply := func(t *today.Today) error {
+		if *update {
+			t.Update()
+		}
+		if *sort {
+			t.Sort()
+		}
+		if *clear {
+			t.Clear()
+		}
+		return nil
 	}
 
 	if *pipe {
-		out = os.Stdout
-	} else {
-		f, err := openWriteToday(*dir)
+		t, err := today.Parse(os.Stdin)
 		if err != nil {
-			log.Fatalf("Failed to write todayfile: %s", err)
+			log.Fatalf("Failed to parse today: %s", err)
+		}
+		apply(t)
+		if err := t.Write(os.Stdout); err != nil {
+			log.Fatalf("Failed to write today: %s", err)
 		}
-		defer f.Close()
-		out = f
+		return
 	}
-	err = t.Write(out)
+
+	generated, err := rolloverToday(*dir)
 	if err != nil {
-		log.Fatalf("Failed to write today: %s", err)
+		log.Fatalf("Failed to generate todayfile: %s", err)
+	}
+	if generated {
+		return
+	}
+	if err := updateToday(*dir, apply); err != nil {
+		log.Fatalf("Failed to update today: %s", err)
 	}
 }
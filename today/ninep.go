@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/knusbaum/today"
+	"github.com/knusbaum/today/ninep"
+)
+
+// todayFS presents the current day's today file as a file tree for today 9p:
+//
+//	/tasks/new               write task lines, as they appear under TODO, to add tasks
+//	/tasks/NAME/description
+//	/tasks/NAME/status       e.g. "IN PROGRESS - pr #12 - Jan 16, 2020"
+//	/tasks/NAME/comments     one comment per line
+//	/startup/N/description
+//	/startup/N/status
+//	/log                     append-only; each line written is added as a Log entry
+//	/notes
+//
+// Removing /tasks/NAME or /startup/N removes the task or Startup item. Reads use readToday, and
+// every write goes through updateToday, after which the today file is updated and sorted.
+type todayFS struct {
+	dir string
+}
+
+const (
+	todayFSDir  = ninep.DMDIR | 0555
+	todayFSFile = 0644
+)
+
+// todayNode is a file in a todayFS. Directories have names, and files have get and set.
+type todayNode struct {
+	mode  uint32
+	names []string
+	get   func() string
+	set   func(s string) error
+}
+
+// textLines returns the lines of s without the final newline. It returns nil if s is empty.
+func textLines(s string) []string {
+	s = strings.TrimRight(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// statusLine returns s as it appears between square brackets in a today file.
+func statusLine(s today.Status) string {
+	text := statusText(s)
+	if !s.Date.IsZero() {
+		if text != "" {
+			text += " - "
+		}
+		text += s.Date.Format("Jan _2, 2006")
+	}
+	return text + "\n"
+}
+
+func statusNode(s *today.Status) *todayNode {
+	return &todayNode{
+		mode: todayFSFile,
+		get:  func() string { return statusLine(*s) },
+		set: func(text string) error {
+			// A status written without a date is dated and logged by Update, just like one typed
+			// into the today file.
+			*s = today.ParseStatus(text)
+			return nil
+		},
+	}
+}
+
+func descriptionNode(d *string) *todayNode {
+	return &todayNode{
+		mode: todayFSFile,
+		get:  func() string { return *d + "\n" },
+		set: func(text string) error {
+			*d = strings.Join(strings.Fields(text), " ")
+			return nil
+		},
+	}
+}
+
+// lookup returns the node for path in t. Setting the node changes t.
+func (fs *todayFS) lookup(t *today.Today, p []string) (*todayNode, error) {
+	if len(p) == 0 {
+		return &todayNode{mode: todayFSDir, names: []string{"log", "notes", "startup", "tasks"}}, nil
+	}
+	switch p[0] {
+	case "log":
+		if len(p) == 1 {
+			return &todayNode{
+				mode: ninep.DMAPPEND | todayFSFile,
+				get:  func() string { return joinLines(t.Log) },
+				set: func(text string) error {
+					for _, line := range textLines(text) {
+						if line = strings.TrimSpace(line); line != "" {
+							t.Log.Append(today.NewLogEntry(line))
+						}
+					}
+					return nil
+				},
+			}, nil
+		}
+	case "notes":
+		if len(p) == 1 {
+			return &todayNode{
+				mode: todayFSFile,
+				get:  func() string { return joinLines(t.Notes) },
+				set: func(text string) error {
+					t.Notes = textLines(text)
+					return nil
+				},
+			}, nil
+		}
+	case "tasks":
+		if len(p) == 1 {
+			names := []string{"new"}
+			for _, task := range t.Tasks.Tasks {
+				if task.Name != "" {
+					names = append(names, task.Name)
+				}
+			}
+			return &todayNode{mode: todayFSDir, names: names}, nil
+		}
+		if p[1] == "new" {
+			if len(p) > 2 {
+				break
+			}
+			return &todayNode{
+				mode: 0222,
+				set: func(text string) error {
+					// Parse the lines as the TODO section of an otherwise empty today file.
+					var b bytes.Buffer
+					if err := new(today.Today).Write(&b); err != nil {
+						return err
+					}
+					b.WriteString(text)
+					if !strings.HasSuffix(text, "\n") {
+						b.WriteString("\n")
+					}
+					added, err := today.Parse(&b)
+					if err != nil {
+						return err
+					}
+					for _, task := range added.Tasks.Tasks {
+						if task.Name != "" && t.Tasks.Find(task.Name) != nil {
+							return os.ErrExist
+						}
+					}
+					t.Tasks.Tasks = append(t.Tasks.Tasks, added.Tasks.Tasks...)
+					return nil
+				},
+			}, nil
+		}
+		task := t.Tasks.Find(p[1])
+		if task == nil {
+			break
+		}
+		if len(p) == 2 {
+			return &todayNode{mode: todayFSDir, names: []string{"comments", "description", "status"}}, nil
+		}
+		if len(p) > 3 {
+			break
+		}
+		switch p[2] {
+		case "description":
+			return descriptionNode(&task.Description), nil
+		case "status":
+			return statusNode(&task.Status), nil
+		case "comments":
+			return &todayNode{
+				mode: todayFSFile,
+				get:  func() string { return joinLines(task.Comments) },
+				set: func(text string) error {
+					task.Comments = nil
+					for _, line := range textLines(text) {
+						if line = strings.TrimSpace(line); line != "" {
+							task.Comments = append(task.Comments, line)
+						}
+					}
+					return nil
+				},
+			}, nil
+		}
+	case "startup":
+		if len(p) == 1 {
+			var names []string
+			for i := range t.Startup {
+				names = append(names, strconv.Itoa(i+1))
+			}
+			return &todayNode{mode: todayFSDir, names: names}, nil
+		}
+		n, err := strconv.Atoi(p[1])
+		if err != nil || n < 1 || n > len(t.Startup) || p[1] != strconv.Itoa(n) {
+			break
+		}
+		item := t.Startup[n-1]
+		if len(p) == 2 {
+			return &todayNode{mode: todayFSDir, names: []string{"description", "status"}}, nil
+		}
+		if len(p) > 3 {
+			break
+		}
+		switch p[2] {
+		case "description":
+			return descriptionNode(&item.Description), nil
+		case "status":
+			return statusNode(&item.Status), nil
+		}
+	}
+	return nil, os.ErrNotExist
+}
+
+// read reads the today file, returning it and when it was last changed.
+func (fs *todayFS) read() (*today.Today, time.Time, error) {
+	t, err := readToday(fs.dir)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	mtime := time.Now()
+	if files, err := noteFiles(fs.dir); err == nil {
+		if fi, err := os.Stat(path.Join(fs.dir, files[0].name)); err == nil {
+			mtime = fi.ModTime()
+		}
+	}
+	return t, mtime, nil
+}
+
+func (fs *todayFS) dirOf(n *todayNode, p []string, mtime time.Time) ninep.Dir {
+	d := ninep.Dir{Mode: n.mode, Mtime: mtime}
+	if len(p) > 0 {
+		d.Name = p[len(p)-1]
+	}
+	if n.get != nil {
+		d.Length = uint64(len(n.get()))
+	}
+	return d
+}
+
+func (fs *todayFS) Stat(p []string) (ninep.Dir, error) {
+	t, mtime, err := fs.read()
+	if err != nil {
+		return ninep.Dir{}, err
+	}
+	n, err := fs.lookup(t, p)
+	if err != nil {
+		return ninep.Dir{}, err
+	}
+	return fs.dirOf(n, p, mtime), nil
+}
+
+func (fs *todayFS) ReadDir(p []string) ([]ninep.Dir, error) {
+	t, mtime, err := fs.read()
+	if err != nil {
+		return nil, err
+	}
+	n, err := fs.lookup(t, p)
+	if err != nil {
+		return nil, err
+	}
+	var dirs []ninep.Dir
+	for _, name := range n.names {
+		cp := append(append([]string(nil), p...), name)
+		if c, err := fs.lookup(t, cp); err == nil {
+			dirs = append(dirs, fs.dirOf(c, cp, mtime))
+		}
+	}
+	return dirs, nil
+}
+
+func (fs *todayFS) ReadFile(p []string) ([]byte, error) {
+	t, _, err := fs.read()
+	if err != nil {
+		return nil, err
+	}
+	n, err := fs.lookup(t, p)
+	if err != nil {
+		return nil, err
+	}
+	if n.get == nil {
+		return nil, nil
+	}
+	return []byte(n.get()), nil
+}
+
+func (fs *todayFS) WriteFile(p []string, data []byte) error {
+	return updateToday(fs.dir, func(t *today.Today) error {
+		n, err := fs.lookup(t, p)
+		if err != nil {
+			return err
+		}
+		if n.set == nil {
+			return os.ErrPermission
+		}
+		if err := n.set(string(data)); err != nil {
+			return err
+		}
+		t.Update()
+		t.Sort()
+		return nil
+	})
+}
+
+func (fs *todayFS) Remove(p []string) error {
+	return updateToday(fs.dir, func(t *today.Today) error {
+		if _, err := fs.lookup(t, p); err != nil {
+			return err
+		}
+		switch {
+		case len(p) == 2 && p[0] == "tasks" && p[1] != "new":
+			var tasks []*today.Task
+			for _, task := range t.Tasks.Tasks {
+				if task.Name != p[1] {
+					tasks = append(tasks, task)
+				}
+			}
+			t.Tasks.Tasks = tasks
+		case len(p) == 2 && p[0] == "startup":
+			n, _ := strconv.Atoi(p[1])
+			t.Startup = append(t.Startup[:n-1], t.Startup[n:]...)
+		default:
+			return os.ErrPermission
+		}
+		t.Update()
+		return nil
+	})
+}
+
+// ninepCmd serves the today file in dir as a file tree over 9P. (See todayFS)
+func ninepCmd(dir string, args []string) error {
+	fs := flag.NewFlagSet("9p", flag.ExitOnError)
+	network := fs.String("net", "tcp", `The network to listen on, "tcp" or "unix".`)
+	addr := fs.String("addr", "127.0.0.1:5640", "The address to listen on.")
+	fs.Parse(args)
+
+	l, err := net.Listen(*network, *addr)
+	if err != nil {
+		return err
+	}
+	log.Printf("Serving %s over 9P on %s!%s", dir, *network, *addr)
+	return ninep.Serve(l, &todayFS{dir: dir})
+}
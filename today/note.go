@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/knusbaum/today"
+)
+
+const noteUsage = `usage: today note get KEY
+       today note set KEY VALUE...
+       today note rm KEY
+       today note ls
+       today note new TITLE...
+       today note check`
+
+// notesDir is the subdirectory of the today directory holding long-form note files.
+const notesDir = "notes"
+
+var nonSlugRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slug turns a note title into something usable as a file name and note key.
+func slug(title string) string {
+	return strings.Trim(nonSlugRe.ReplaceAllString(strings.ToLower(title), "-"), "-")
+}
+
+// validNoteKey reports whether key can be read back as the key of a keyed note. (See
+// today.Lines.Keys)
+func validNoteKey(key string) bool {
+	keys := (today.Lines{key + ": -"}).Keys()
+	return len(keys) == 1 && keys[0] == key
+}
+
+// noteRefs returns the note files referenced by notes, relative to the today directory. Any word
+// beginning with notesDir and a slash is a reference.
+func noteRefs(notes []string) []string {
+	var refs []string
+	for _, line := range notes {
+		for _, word := range strings.Fields(line) {
+			if strings.HasPrefix(word, notesDir+"/") {
+				refs = append(refs, path.Clean(word))
+			}
+		}
+	}
+	return refs
+}
+
+// newNote creates a dated note file in the notes directory, adds a keyed reference to it to the
+// Notes section, and opens it in $EDITOR. If the reference can't be added, the file is removed
+// again, so that it isn't left unreferenced.
+func newNote(dir, title string) error {
+	s := slug(title)
+	if s == "" {
+		return fmt.Errorf("bad note title %q", title)
+	}
+	rel := path.Join(notesDir, time.Now().Format("2006-01-02")+"-"+s+".txt")
+	name := path.Join(dir, rel)
+	if err := os.MkdirAll(path.Join(dir, notesDir), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(f, "%s\n\n", title)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	err = updateToday(dir, func(t *today.Today) error {
+		key := s
+		for i := 2; ; i++ {
+			if _, ok := t.Notes.Get(key); !ok {
+				break
+			}
+			key = fmt.Sprintf("%s-%d", s, i)
+		}
+		t.Notes.Set(key, rel)
+		return nil
+	})
+	if err != nil {
+		os.Remove(name)
+		return err
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	cmd := exec.Command(editor, name)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// checkNotes reports note references that point at missing files, and note files that nothing
+// references.
+func checkNotes(dir string) error {
+	t, err := readToday(dir)
+	if err != nil {
+		return err
+	}
+	problems := 0
+	referenced := make(map[string]bool)
+	for _, ref := range noteRefs(t.Notes) {
+		referenced[ref] = true
+		if _, err := os.Stat(path.Join(dir, ref)); os.IsNotExist(err) {
+			fmt.Printf("missing: %s\n", ref)
+			problems++
+		}
+	}
+
+	files, err := ioutil.ReadDir(path.Join(dir, notesDir))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, fi := range files {
+		rel := path.Join(notesDir, fi.Name())
+		if !fi.IsDir() && !referenced[rel] {
+			fmt.Printf("unreferenced: %s\n", rel)
+			problems++
+		}
+	}
+	if problems > 0 {
+		return fmt.Errorf("%d problems found", problems)
+	}
+	return nil
+}
+
+// noteCmd reads and changes keyed notes in the Notes section of the current today file, and manages
+// the long-form note files they refer to. (See today.Lines.Get)
+func noteCmd(dir string, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(noteUsage)
+	}
+	switch cmd, args := args[0], args[1:]; {
+	case cmd == "get" && len(args) == 1:
+		t, err := readToday(dir)
+		if err != nil {
+			return err
+		}
+		v, ok := t.Notes.Get(args[0])
+		if !ok {
+			return fmt.Errorf("no note %s", args[0])
+		}
+		fmt.Println(v)
+		return nil
+	case cmd == "set" && len(args) >= 2:
+		if !validNoteKey(args[0]) {
+			return fmt.Errorf("bad key %q", args[0])
+		}
+		return updateToday(dir, func(t *today.Today) error {
+			t.Notes.Set(args[0], strings.Join(args[1:], " "))
+			return nil
+		})
+	case cmd == "rm" && len(args) == 1:
+		return updateToday(dir, func(t *today.Today) error {
+			if !t.Notes.Remove(args[0]) {
+				return fmt.Errorf("no note %s", args[0])
+			}
+			return nil
+		})
+	case cmd == "new" && len(args) >= 1:
+		return newNote(dir, strings.Join(args, " "))
+	case cmd == "check" && len(args) == 0:
+		return checkNotes(dir)
+	case cmd == "ls" && len(args) == 0:
+		t, err := readToday(dir)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		for _, k := range t.Notes.Keys() {
+			v, _ := t.Notes.Get(k)
+			fmt.Fprintf(w, "%s\t%s\n", k, v)
+		}
+		return w.Flush()
+	}
+	return fmt.Errorf(noteUsage)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const noteTestDoc = `Morning Start Up:
+
+Notes:
+deploy: make deploy
+
+Log:
+
+TODO:
+`
+
+// noteTestDir returns a today directory with noteTestDoc as the current day's today file.
+func noteTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "today-note")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := path.Join(dir, time.Now().Format(noteFormat))
+	if err := ioutil.WriteFile(name, []byte(noteTestDoc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestNoteSet(t *testing.T) {
+	dir := noteTestDir(t)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, noteCmd(dir, []string{"set", "build", "make", "all"}))
+	tdy, err := readToday(dir)
+	assert.NoError(t, err)
+	v, ok := tdy.Notes.Get("build")
+	assert.True(t, ok)
+	assert.Equal(t, "make all", v)
+
+	// Keys that couldn't be read back are rejected, rather than adding a line each time.
+	for _, key := range []string{"foo/bar", "foo: bar", "-foo", "foo bar"} {
+		assert.EqualError(t, noteCmd(dir, []string{"set", key, "x"}), `bad key "`+key+`"`)
+	}
+	after, err := readToday(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, tdy.Notes, after.Notes)
+}
+
+func TestNoteNew(t *testing.T) {
+	dir := noteTestDir(t)
+	defer os.RemoveAll(dir)
+	defer os.Setenv("EDITOR", os.Getenv("EDITOR"))
+	os.Setenv("EDITOR", "true")
+
+	rel := path.Join(notesDir, time.Now().Format("2006-01-02")+"-design-review.txt")
+	assert.NoError(t, noteCmd(dir, []string{"new", "Design", "Review!"}))
+	b, err := ioutil.ReadFile(path.Join(dir, rel))
+	assert.NoError(t, err)
+	assert.Equal(t, "Design Review!\n\n", string(b))
+	tdy, err := readToday(dir)
+	assert.NoError(t, err)
+	v, _ := tdy.Notes.Get("design-review")
+	assert.Equal(t, rel, v)
+
+	// The same note can't be created twice in a day.
+	assert.Error(t, noteCmd(dir, []string{"new", "Design", "Review"}))
+	after, err := readToday(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, tdy.Notes, after.Notes)
+
+	// The file isn't left behind when the today file can't be changed.
+	broken, err := ioutil.TempDir("", "today-note")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(broken)
+	if err := os.Mkdir(path.Join(broken, time.Now().Format(noteFormat)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	assert.Error(t, noteCmd(broken, []string{"new", "Design", "Review"}))
+	_, err = os.Stat(path.Join(broken, rel))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestNoteCheck(t *testing.T) {
+	dir := noteTestDir(t)
+	defer os.RemoveAll(dir)
+	if err := os.Mkdir(path.Join(dir, notesDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(dir, notesDir, "extra.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, noteCmd(dir, []string{"set", "design", "see notes/design.txt"}))
+
+	// notes/design.txt is missing, and notes/extra.txt isn't referenced.
+	assert.EqualError(t, noteCmd(dir, []string{"check"}), "2 problems found")
+
+	if err := ioutil.WriteFile(path.Join(dir, notesDir, "design.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, noteCmd(dir, []string{"set", "extra", "notes/extra.txt"}))
+	assert.NoError(t, noteCmd(dir, []string{"check"}))
+}
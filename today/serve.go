@@ -0,0 +1,623 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/knusbaum/today"
+)
+
+type jsonStatus struct {
+	Name    string `json:"name"`
+	Comment string `json:"comment,omitempty"`
+	Date    string `json:"date,omitempty"`
+}
+
+type jsonTask struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Status      jsonStatus `json:"status"`
+	Comments    []string   `json:"comments"`
+	Rank        int        `json:"rank"`
+	Bucket      string     `json:"bucket"`
+	Resurfaced  string     `json:"resurfaced,omitempty"`
+}
+
+type jsonStartupItem struct {
+	Number      int        `json:"number"`
+	Description string     `json:"description"`
+	Schedule    string     `json:"schedule,omitempty"`
+	Status      jsonStatus `json:"status"`
+}
+
+type jsonNote struct {
+	Key   string `json:"key,omitempty"`
+	Value string `json:"value"`
+}
+
+type jsonLogEntry struct {
+	Time    string `json:"time,omitempty"`
+	Kind    string `json:"kind"`
+	Task    string `json:"task,omitempty"`
+	Message string `json:"message"`
+}
+
+func toJSONStatus(s today.Status) jsonStatus {
+	js := jsonStatus{Name: s.Name, Comment: s.Comment}
+	if !s.Date.IsZero() {
+		js.Date = s.Date.Format("2006-01-02")
+	}
+	return js
+}
+
+func toJSONTask(t *today.Task) jsonTask {
+	comments := t.Comments
+	if comments == nil {
+		comments = []string{}
+	}
+	rank := t.Status.Priority()
+	return jsonTask{
+		Name:        t.Name,
+		Description: t.Description,
+		Status:      toJSONStatus(t.Status),
+		Comments:    comments,
+		Rank:        rank,
+		Bucket:      bucketName(rank),
+		Resurfaced:  t.Status.Resurfaced(),
+	}
+}
+
+func toJSONStartup(l today.List) []jsonStartupItem {
+	items := make([]jsonStartupItem, 0, len(l))
+	for i, item := range l {
+		items = append(items, jsonStartupItem{
+			Number:      i + 1,
+			Description: item.Description,
+			Schedule:    item.Schedule,
+			Status:      toJSONStatus(item.Status),
+		})
+	}
+	return items
+}
+
+// apiError is an error with the HTTP status code it should be reported with.
+type apiError struct {
+	code int
+	msg  string
+}
+
+func (e *apiError) Error() string { return e.msg }
+
+func errorf(code int, format string, args ...interface{}) error {
+	return &apiError{code, fmt.Sprintf(format, args...)}
+}
+
+// apiHandler handles an API request, returning the status code and the value to send as JSON.
+type apiHandler func(r *http.Request, id string) (int, interface{}, error)
+
+// api routes the requests under prefix to the handlers for their methods. Whatever follows the
+// prefix in the path is passed to the handler as id.
+func api(prefix string, handlers map[string]apiHandler) (string, http.HandlerFunc) {
+	return prefix, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		h, ok := handlers[r.Method]
+		if !ok {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(map[string]string{"error": "method not allowed"})
+			return
+		}
+		code, v, err := h(r, strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/"))
+		if err != nil {
+			code = http.StatusInternalServerError
+			if ae, ok := err.(*apiError); ok {
+				code = ae.code
+			}
+			v = map[string]string{"error": err.Error()}
+		}
+		w.WriteHeader(code)
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(v)
+	}
+}
+
+// decode reads the JSON request body into v.
+func decode(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errorf(http.StatusBadRequest, "bad request body: %v", err)
+	}
+	return nil
+}
+
+// server serves the REST API of today serve. Reads use readToday, and every change goes through
+// updateToday, which holds the directory lock while it reads and writes the today file.
+type server struct {
+	dir  string
+	addr string // The address the server listens on.
+}
+
+// allowedHost reports whether a request with the Host header host may use the API: an IP address,
+// localhost, or the host s listens on. Anything else is a name that may have been pointed at the
+// server by someone else's DNS, to reach the API from their pages.
+func (s *server) allowedHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+	if strings.EqualFold(host, "localhost") || net.ParseIP(host) != nil {
+		return true
+	}
+	listen, _, err := net.SplitHostPort(s.addr)
+	return err == nil && listen != "" && strings.EqualFold(host, listen)
+}
+
+// guard rejects requests that a web page from another site could have made: those with a Host s
+// doesn't answer to, an Origin other than the server itself, or, for changes, a body that isn't
+// JSON. Browsers send pages' cross-site JSON requests only after asking with OPTIONS, which the API
+// never allows.
+func (s *server) guard(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		refuse := func(code int, msg string) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(code)
+			json.NewEncoder(w).Encode(map[string]string{"error": msg})
+		}
+		if !s.allowedHost(r.Host) {
+			refuse(http.StatusForbidden, fmt.Sprintf("unknown host %q", r.Host))
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			if u, err := url.Parse(origin); err != nil || !strings.EqualFold(u.Host, r.Host) {
+				refuse(http.StatusForbidden, fmt.Sprintf("cross-origin request from %s", origin))
+				return
+			}
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			if t, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); t != "application/json" {
+				refuse(http.StatusUnsupportedMediaType, "requests that change the today file must be application/json")
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// taskChange is the body of POST and PATCH requests for tasks. Fields that are left out are left
+// alone. Setting the status or its comment gives the task a new undated Status, which Update dates
+// and logs. HoldUntil puts the task on "HOLD" until a date, in any form parseHoldDate takes.
+type taskChange struct {
+	Name          string    `json:"name"`
+	Description   *string   `json:"description"`
+	Status        *string   `json:"status"`
+	StatusComment *string   `json:"status_comment"`
+	Comments      *[]string `json:"comments"`
+	AddComment    string    `json:"add_comment"`
+	HoldUntil     string    `json:"hold_until"`
+
+	hold time.Time // HoldUntil, parsed by check.
+}
+
+// taskNameRe matches the names a task can have in a today file, like "JIRA-12".
+var taskNameRe = regexp.MustCompile(`^[A-Z]+-[0-9]+$`)
+
+// oneLine returns an error if any of fields has a line break in it, since it would be read back as
+// more than one line of the today file.
+func oneLine(fields ...string) error {
+	for _, f := range fields {
+		if strings.ContainsAny(f, "\r\n") {
+			return fmt.Errorf("%q has a line break in it", f)
+		}
+	}
+	return nil
+}
+
+// check returns an error if c has a name that isn't a task name, a field that isn't one line, or a
+// date to hold the task until that can't be parsed.
+func (c *taskChange) check() error {
+	if c.Name != "" && !taskNameRe.MatchString(c.Name) {
+		return fmt.Errorf("bad task name %q", c.Name)
+	}
+	if c.HoldUntil != "" {
+		date, err := parseHoldDate(c.HoldUntil, time.Now())
+		if err != nil {
+			return err
+		}
+		c.hold = date
+	}
+	fields := []string{c.AddComment}
+	for _, f := range []*string{c.Description, c.Status, c.StatusComment} {
+		if f != nil {
+			fields = append(fields, *f)
+		}
+	}
+	if c.Comments != nil {
+		fields = append(fields, *c.Comments...)
+	}
+	return oneLine(fields...)
+}
+
+func (c *taskChange) apply(task *today.Task) {
+	if c.Description != nil {
+		task.Description = *c.Description
+	}
+	if c.Status != nil || c.StatusComment != nil {
+		status := today.Status{Name: task.Status.Name, Comment: task.Status.Comment}
+		if c.Status != nil {
+			status.Name = strings.ToUpper(*c.Status)
+		}
+		if c.StatusComment != nil {
+			status.Comment = *c.StatusComment
+		}
+		task.Status = status
+	}
+	if !c.hold.IsZero() {
+		task.Status = today.Status{Name: "HOLD", Comment: task.Status.Comment}
+		if c.StatusComment != nil {
+			task.Status.Comment = *c.StatusComment
+		}
+	}
+	if c.Comments != nil {
+		task.Comments = *c.Comments
+	}
+	if c.AddComment != "" {
+		task.Comments = append(task.Comments, c.AddComment)
+	}
+}
+
+// applyHold gives a task put on "HOLD" by c its release date. It's called after Update, which dates
+// and logs the new status with today's date, as the hold command in the tui does.
+func (c *taskChange) applyHold(task *today.Task) {
+	if !c.hold.IsZero() {
+		task.Status.Date = c.hold
+	}
+}
+
+func (s *server) getTasks(r *http.Request, name string) (int, interface{}, error) {
+	t, err := readToday(s.dir)
+	if err != nil {
+		return 0, nil, err
+	}
+	t.Sort()
+	if name != "" {
+		task := t.Tasks.Find(name)
+		if task == nil {
+			return 0, nil, errorf(http.StatusNotFound, "no task named %s", name)
+		}
+		return http.StatusOK, toJSONTask(task), nil
+	}
+	status := strings.ToUpper(r.URL.Query().Get("status"))
+	tasks := []jsonTask{}
+	for _, task := range t.Tasks.Tasks {
+		if status == "" || task.Status.Name == status {
+			tasks = append(tasks, toJSONTask(task))
+		}
+	}
+	return http.StatusOK, tasks, nil
+}
+
+func (s *server) postTask(r *http.Request, name string) (int, interface{}, error) {
+	if name != "" {
+		return 0, nil, errorf(http.StatusMethodNotAllowed, "POST to /tasks to add a task")
+	}
+	var c taskChange
+	if err := decode(r, &c); err != nil {
+		return 0, nil, err
+	}
+	if c.Description == nil || *c.Description == "" {
+		return 0, nil, errorf(http.StatusBadRequest, "a task needs a description")
+	}
+	if err := c.check(); err != nil {
+		return 0, nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+	task := &today.Task{Name: c.Name}
+	err := updateToday(s.dir, func(t *today.Today) error {
+		if c.Name != "" && t.Tasks.Find(c.Name) != nil {
+			return errorf(http.StatusConflict, "there is already a task named %s", c.Name)
+		}
+		c.apply(task)
+		t.Tasks.Tasks = append(t.Tasks.Tasks, task)
+		t.Update()
+		c.applyHold(task)
+		t.Sort()
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, toJSONTask(task), nil
+}
+
+func (s *server) patchTask(r *http.Request, name string) (int, interface{}, error) {
+	var c taskChange
+	if err := decode(r, &c); err != nil {
+		return 0, nil, err
+	}
+	if err := c.check(); err != nil {
+		return 0, nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+	var task *today.Task
+	err := updateToday(s.dir, func(t *today.Today) error {
+		if task = t.Tasks.Find(name); task == nil {
+			return errorf(http.StatusNotFound, "no task named %s", name)
+		}
+		c.apply(task)
+		t.Update()
+		c.applyHold(task)
+		t.Sort()
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, toJSONTask(task), nil
+}
+
+// startupChange is the body of POST and PATCH requests for Startup items.
+type startupChange struct {
+	Description   string  `json:"description"`
+	Schedule      string  `json:"schedule"`
+	Status        *string `json:"status"`
+	StatusComment *string `json:"status_comment"`
+}
+
+// check returns an error if a field of c isn't one line.
+func (c *startupChange) check() error {
+	fields := []string{c.Description, c.Schedule}
+	for _, f := range []*string{c.Status, c.StatusComment} {
+		if f != nil {
+			fields = append(fields, *f)
+		}
+	}
+	return oneLine(fields...)
+}
+
+func (s *server) getStartup(r *http.Request, id string) (int, interface{}, error) {
+	t, err := readToday(s.dir)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, toJSONStartup(t.Startup), nil
+}
+
+func (s *server) postStartup(r *http.Request, id string) (int, interface{}, error) {
+	var c startupChange
+	if err := decode(r, &c); err != nil {
+		return 0, nil, err
+	}
+	if c.Description == "" {
+		return 0, nil, errorf(http.StatusBadRequest, "a startup item needs a description")
+	}
+	if err := c.check(); err != nil {
+		return 0, nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+	var items []jsonStartupItem
+	err := updateToday(s.dir, func(t *today.Today) error {
+		t.Startup = append(t.Startup, &today.ListItem{Description: c.Description, Schedule: c.Schedule})
+		t.Update()
+		items = toJSONStartup(t.Startup)
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, items[len(items)-1], nil
+}
+
+func (s *server) patchStartup(r *http.Request, id string) (int, interface{}, error) {
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return 0, nil, errorf(http.StatusNotFound, "no startup item %q", id)
+	}
+	var c startupChange
+	if err := decode(r, &c); err != nil {
+		return 0, nil, err
+	}
+	if err := c.check(); err != nil {
+		return 0, nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+	var items []jsonStartupItem
+	err = updateToday(s.dir, func(t *today.Today) error {
+		if n < 1 || n > len(t.Startup) {
+			return errorf(http.StatusNotFound, "no startup item %d", n)
+		}
+		item := t.Startup[n-1]
+		if c.Description != "" {
+			item.Description = c.Description
+		}
+		if c.Status != nil || c.StatusComment != nil {
+			status := today.Status{Name: item.Status.Name, Comment: item.Status.Comment}
+			if c.Status != nil {
+				status.Name = strings.ToUpper(*c.Status)
+			}
+			if c.StatusComment != nil {
+				status.Comment = *c.StatusComment
+			}
+			item.Status = status
+		}
+		t.Update()
+		items = toJSONStartup(t.Startup)
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, items[n-1], nil
+}
+
+func (s *server) getNotes(r *http.Request, key string) (int, interface{}, error) {
+	t, err := readToday(s.dir)
+	if err != nil {
+		return 0, nil, err
+	}
+	if key != "" {
+		v, ok := t.Notes.Get(key)
+		if !ok {
+			return 0, nil, errorf(http.StatusNotFound, "no note %s", key)
+		}
+		return http.StatusOK, jsonNote{key, v}, nil
+	}
+	notes := []jsonNote{}
+	for _, line := range t.Notes {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		note := jsonNote{Value: line}
+		if keys := (today.Lines{line}).Keys(); len(keys) > 0 {
+			note.Key = keys[0]
+			note.Value, _ = (today.Lines{line}).Get(note.Key)
+		}
+		notes = append(notes, note)
+	}
+	return http.StatusOK, notes, nil
+}
+
+// setNote adds or replaces a note. A POST to /notes with a key, or a PATCH to /notes/KEY, sets a
+// keyed note. A POST without a key adds an ordinary note.
+func (s *server) setNote(r *http.Request, key string) (int, interface{}, error) {
+	var note jsonNote
+	if err := decode(r, &note); err != nil {
+		return 0, nil, err
+	}
+	if key == "" {
+		key = note.Key
+	}
+	if key != "" && !validNoteKey(key) {
+		return 0, nil, errorf(http.StatusBadRequest, "bad key %q", key)
+	}
+	if note.Value == "" {
+		return 0, nil, errorf(http.StatusBadRequest, "a note needs a value")
+	}
+	if err := oneLine(note.Value); err != nil {
+		return 0, nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+	err := updateToday(s.dir, func(t *today.Today) error {
+		if key == "" {
+			t.Notes.Add(note.Value)
+			return nil
+		}
+		if _, ok := t.Notes.Get(key); !ok && r.Method == http.MethodPatch {
+			return errorf(http.StatusNotFound, "no note %s", key)
+		}
+		t.Notes.Set(key, note.Value)
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	code := http.StatusOK
+	if r.Method == http.MethodPost {
+		code = http.StatusCreated
+	}
+	return code, jsonNote{key, note.Value}, nil
+}
+
+func toJSONLogEntry(e today.LogEntry) jsonLogEntry {
+	je := jsonLogEntry{Kind: e.Kind.String(), Task: e.Task, Message: e.Message}
+	if !e.Time.IsZero() {
+		je.Time = e.Time.Format(time.RFC3339)
+	}
+	return je
+}
+
+func (s *server) getLog(r *http.Request, id string) (int, interface{}, error) {
+	t, date, err := readTodayDate(s.dir)
+	if err != nil {
+		return 0, nil, err
+	}
+	entries := []jsonLogEntry{}
+	for _, e := range t.Log.Entries(date) {
+		if e.Kind != today.LogText || strings.TrimSpace(e.Message) != "" {
+			entries = append(entries, toJSONLogEntry(e))
+		}
+	}
+	return http.StatusOK, entries, nil
+}
+
+func (s *server) postLog(r *http.Request, id string) (int, interface{}, error) {
+	var body struct {
+		Message string `json:"message"`
+	}
+	if err := decode(r, &body); err != nil {
+		return 0, nil, err
+	}
+	if body.Message == "" {
+		return 0, nil, errorf(http.StatusBadRequest, "a log entry needs a message")
+	}
+	if err := oneLine(body.Message); err != nil {
+		return 0, nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+	e := today.NewLogEntry(body.Message)
+	err := updateToday(s.dir, func(t *today.Today) error {
+		t.Log.Append(e)
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, toJSONLogEntry(e), nil
+}
+
+// action returns a handler that applies change to the today file and returns the sorted tasks.
+func (s *server) action(change func(t *today.Today)) apiHandler {
+	return func(r *http.Request, id string) (int, interface{}, error) {
+		tasks := []jsonTask{}
+		err := updateToday(s.dir, func(t *today.Today) error {
+			change(t)
+			for _, task := range t.Tasks.Tasks {
+				tasks = append(tasks, toJSONTask(task))
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusOK, tasks, nil
+	}
+}
+
+func (s *server) rollover(r *http.Request, id string) (int, interface{}, error) {
+	generated, err := rolloverToday(s.dir)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, map[string]interface{}{
+		"date":      time.Now().Format("2006-01-02"),
+		"generated": generated,
+	}, nil
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(api("/tasks", map[string]apiHandler{"GET": s.getTasks, "POST": s.postTask}))
+	mux.HandleFunc(api("/tasks/", map[string]apiHandler{"GET": s.getTasks, "PATCH": s.patchTask}))
+	mux.HandleFunc(api("/startup", map[string]apiHandler{"GET": s.getStartup, "POST": s.postStartup}))
+	mux.HandleFunc(api("/startup/", map[string]apiHandler{"PATCH": s.patchStartup}))
+	mux.HandleFunc(api("/notes", map[string]apiHandler{"GET": s.getNotes, "POST": s.setNote}))
+	mux.HandleFunc(api("/notes/", map[string]apiHandler{"GET": s.getNotes, "PATCH": s.setNote}))
+	mux.HandleFunc(api("/log", map[string]apiHandler{"GET": s.getLog, "POST": s.postLog}))
+	mux.HandleFunc(api("/update", map[string]apiHandler{"POST": s.action(func(t *today.Today) { t.Update(); t.Sort() })}))
+	mux.HandleFunc(api("/sort", map[string]apiHandler{"POST": s.action(func(t *today.Today) { t.Sort() })}))
+	mux.HandleFunc(api("/rollover", map[string]apiHandler{"POST": s.rollover}))
+	return s.guard(mux)
+}
+
+// serveCmd serves a REST API for the today files in dir over HTTP.
+func serveCmd(dir string, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:8080", "The address to listen on.")
+	fs.Parse(args)
+
+	s := &server{dir: dir, addr: *addr}
+	log.Printf("Serving %s on http://%s", dir, *addr)
+	return http.ListenAndServe(*addr, s.handler())
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerGuard(t *testing.T) {
+	s := &server{addr: "today.lan:8080"}
+	h := s.guard(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, c := range []struct {
+		method, host, origin, contentType string
+		code                              int
+	}{
+		{"GET", "127.0.0.1:8080", "", "", http.StatusOK},
+		{"GET", "localhost:8080", "", "", http.StatusOK},
+		{"GET", "[::1]:8080", "", "", http.StatusOK},
+		{"GET", "today.lan:8080", "", "", http.StatusOK},
+		{"GET", "evil.example.com:8080", "", "", http.StatusForbidden},
+		{"POST", "127.0.0.1:8080", "", "application/json", http.StatusOK},
+		{"POST", "127.0.0.1:8080", "http://127.0.0.1:8080", "application/json; charset=utf-8", http.StatusOK},
+		{"POST", "127.0.0.1:8080", "", "text/plain", http.StatusUnsupportedMediaType},
+		{"POST", "127.0.0.1:8080", "", "", http.StatusUnsupportedMediaType},
+		{"PATCH", "127.0.0.1:8080", "", "application/x-www-form-urlencoded", http.StatusUnsupportedMediaType},
+		{"POST", "127.0.0.1:8080", "http://evil.example.com", "application/json", http.StatusForbidden},
+		{"GET", "127.0.0.1:8080", "http://evil.example.com", "", http.StatusForbidden},
+	} {
+		r := httptest.NewRequest(c.method, "/log", strings.NewReader("{}"))
+		r.Host = c.host
+		if c.origin != "" {
+			r.Header.Set("Origin", c.origin)
+		}
+		if c.contentType != "" {
+			r.Header.Set("Content-Type", c.contentType)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != c.code {
+			t.Errorf("%s with Host %s, Origin %q, Content-Type %q: got %d, want %d", c.method, c.host, c.origin, c.contentType, w.Code, c.code)
+		}
+	}
+}
+
+// serveTestRequest sends a JSON request to h from the local host, returning the status code.
+func serveTestRequest(h http.Handler, method, target, body string) int {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Host = "127.0.0.1:8080"
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestServerValidation(t *testing.T) {
+	dir := noteTestDir(t)
+	defer os.RemoveAll(dir)
+	h := (&server{dir: dir, addr: "127.0.0.1:8080"}).handler()
+	do := func(method, target, body string) int { return serveTestRequest(h, method, target, body) }
+	assert.Equal(t, http.StatusCreated, do("POST", "/tasks", `{"name": "JIRA-1", "description": "Write it"}`))
+	name := path.Join(dir, time.Now().Format(noteFormat))
+	before, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct{ method, target, body string }{
+		{"POST", "/tasks", `{"name": "jira-2", "description": "Write it"}`},
+		{"POST", "/tasks", `{"name": "JIRA-2 - Oops [DONE]", "description": "Write it"}`},
+		{"POST", "/tasks", `{"description": "Write it\nTODO:"}`},
+		{"POST", "/tasks", `{"description": "Write it", "status": "READY\r"}`},
+		{"POST", "/tasks", `{"description": "Write it", "comments": ["one", "two\nthree"]}`},
+		{"PATCH", "/tasks/JIRA-1", `{"status_comment": "a\nb"}`},
+		{"PATCH", "/tasks/JIRA-1", `{"add_comment": "a\nb"}`},
+		{"POST", "/startup", `{"description": "Stretch\nLog:"}`},
+		{"PATCH", "/startup/1", `{"schedule": "mon\n"}`},
+		{"POST", "/notes", `{"key": "deploy", "value": "make\ndeploy"}`},
+		{"POST", "/log", `{"message": "Lunch\nTODO:"}`},
+	} {
+		if code := do(c.method, c.target, c.body); code != http.StatusBadRequest {
+			t.Errorf("%s %s %s: got %d, want %d", c.method, c.target, c.body, code, http.StatusBadRequest)
+		}
+	}
+	after, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(before), string(after))
+}
+
+func TestServerHold(t *testing.T) {
+	dir := noteTestDir(t)
+	defer os.RemoveAll(dir)
+	h := (&server{dir: dir, addr: "127.0.0.1:8080"}).handler()
+	do := func(method, target, body string) int { return serveTestRequest(h, method, target, body) }
+	assert.Equal(t, http.StatusCreated, do("POST", "/tasks", `{"name": "JIRA-1", "description": "Write it"}`))
+	assert.Equal(t, http.StatusBadRequest, do("PATCH", "/tasks/JIRA-1", `{"hold_until": "someday"}`))
+	assert.Equal(t, http.StatusOK, do("PATCH", "/tasks/JIRA-1", `{"hold_until": "+3"}`))
+
+	tdy, err := readToday(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := tdy.Tasks.Find("JIRA-1")
+	y, m, d := time.Now().Date()
+	assert.Equal(t, "HOLD", task.Status.Name)
+	assert.True(t, time.Date(y, m, d+3, 0, 0, 0, 0, time.Local).Equal(task.Status.Date))
+	assert.Contains(t, tdy.Log[len(tdy.Log)-1], "Moved JIRA-1")
+}
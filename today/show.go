@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// bucketNames names the groups tasks are shown in, by sort priority.
+var bucketNames = map[int]string{
+	0: "Needs attention",
+	1: "In progress",
+	2: "Ready",
+	4: "In review",
+	5: "Waiting",
+	6: "Stale",
+	7: "On hold",
+	8: "Done",
+}
+
+func bucketName(rank int) string {
+	if name, ok := bucketNames[rank]; ok {
+		return name
+	}
+	return "Other"
+}
+
+// relativeDate describes date relative to now in whole calendar days, e.g. "3 days ago" or
+// "due in 2d".
+func relativeDate(date, now time.Time) string {
+	y, m, d := date.Date()
+	from := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	y, m, d = now.Date()
+	to := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	// Days may be 23 or 25 hours long around daylight saving changes.
+	days := int(math.Round(to.Sub(from).Hours() / 24))
+	switch {
+	case days == 0:
+		return "today"
+	case days == 1:
+		return "yesterday"
+	case days > 1:
+		return fmt.Sprintf("%d days ago", days)
+	case days == -1:
+		return "due tomorrow"
+	}
+	return fmt.Sprintf("due in %dd", -days)
+}
+
+type painter struct {
+	w     *bufio.Writer
+	color bool
+}
+
+func (p *painter) paint(style, text string) {
+	if p.color && style != "" {
+		p.w.WriteString(style + text + ansiReset)
+		return
+	}
+	p.w.WriteString(text)
+}
+
+// showCmd prints the current today file to the terminal with colors, grouped by priority. It never
+// changes the file.
+func showCmd(dir string, args []string) error {
+	fs := flag.NewFlagSet("show", flag.ExitOnError)
+	color := fs.Bool("color", term.IsTerminal(int(os.Stdout.Fd())), "Color the output.")
+	fs.Parse(args)
+
+	t, err := readToday(dir)
+	if err != nil {
+		return err
+	}
+	t.Sort()
+
+	now := time.Now()
+	p := &painter{w: bufio.NewWriter(os.Stdout), color: *color}
+
+	p.paint(ansiBold, "Morning Start Up\n")
+	for i, item := range t.Startup {
+		check := "[ ]"
+		if item.Status.Name == "DONE" {
+			check = "[x]"
+		}
+		p.w.WriteString(fmt.Sprintf("  %s %d. %s", check, i+1, item.Description))
+		if item.Status.Name != "" && item.Status.Name != "DONE" {
+			p.w.WriteString(" ")
+			p.paint(statusColor(item.Status.Name), "["+statusText(item.Status)+"]")
+		}
+		p.w.WriteString("\n")
+	}
+
+	p.paint(ansiBold, "\nNotes\n")
+	for _, n := range t.Notes {
+		if n != "" {
+			p.w.WriteString("  " + n + "\n")
+		}
+	}
+
+	p.paint(ansiBold, "\nLog\n")
+	for _, l := range t.Log {
+		p.w.WriteString("  " + l + "\n")
+	}
+
+	bucket := -1
+	for _, task := range t.Tasks.Tasks {
+		if rank := task.Status.Priority(); rank != bucket {
+			bucket = rank
+			p.paint(ansiBold, "\n"+bucketName(rank)+"\n")
+		}
+		p.w.WriteString("  ")
+		if task.Name != "" {
+			p.w.WriteString(task.Name + " - ")
+		}
+		p.w.WriteString(task.Description + " ")
+		p.paint(statusColor(task.Status.Name), "["+statusText(task.Status)+"]")
+		if !task.Status.Date.IsZero() {
+			p.paint(ansiDim, " "+relativeDate(task.Status.Date, now))
+		}
+		p.w.WriteString("\n")
+		if reason := task.Status.Resurfaced(); reason != "" {
+			p.paint(ansiYellow, "      resurfaced: "+reason)
+			p.w.WriteString("\n")
+		}
+		for _, c := range task.Comments {
+			p.paint(ansiDim, "      "+c)
+			p.w.WriteString("\n")
+		}
+	}
+	return p.w.Flush()
+}
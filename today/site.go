@@ -0,0 +1,343 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/knusbaum/today"
+)
+
+const siteTemplates = `
+{{define "top"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.}}</title>
+<link rel="stylesheet" href="style.css">
+</head>
+<body>
+<nav><a href="index.html">Open tasks</a> <a href="days.html">Days</a> <a href="search.html">Search</a></nav>
+<h1>{{.}}</h1>
+{{end}}
+
+{{define "bottom"}}</body>
+</html>
+{{end}}
+
+{{define "status"}}<span class="status {{statusClass .Name}}">[{{statusText .}}]</span>{{end}}
+
+{{define "tasks"}}<table>
+{{range .}}<tr>
+<td>{{if .Name}}<a href="{{taskPage .Name}}">{{.Name}}</a>{{end}}</td>
+<td>{{.Description}}</td>
+<td>{{template "status" .Status}}</td>
+<td>{{if not .Status.Date.IsZero}}{{.Status.Date.Format "Jan _2, 2006"}}{{end}}</td>
+</tr>
+{{range .Comments}}<tr class="comment"><td></td><td colspan="3">{{.}}</td></tr>
+{{end}}{{end}}</table>
+{{end}}
+
+{{define "index"}}{{template "top" "Open tasks"}}
+<p>As of <a href="{{dayPage .Date}}">{{.Date.Format "Monday, Jan _2, 2006"}}</a>.</p>
+{{range .Buckets}}<h2>{{.Name}}</h2>
+{{template "tasks" .Tasks}}{{end}}
+{{template "bottom"}}{{end}}
+
+{{define "days"}}{{template "top" "Days"}}
+<ul>
+{{range .}}<li><a href="{{dayPage .Date}}">{{.Date.Format "Monday, Jan _2, 2006"}}</a> ({{len .Today.Tasks.Tasks}} tasks)</li>
+{{end}}</ul>
+{{template "bottom"}}{{end}}
+
+{{define "day"}}{{template "top" (.Day.Date.Format "Monday, Jan _2, 2006")}}
+<p>{{with .Prev}}<a href="{{dayPage .Date}}">&larr; {{.Date.Format "Jan _2"}}</a>{{end}}
+{{with .Next}}<a href="{{dayPage .Date}}">{{.Date.Format "Jan _2"}} &rarr;</a>{{end}}</p>
+{{with .Day.Today}}
+<h2>Morning Start Up</h2>
+<ul class="startup">
+{{range .Startup}}<li>{{if eq .Status.Name "DONE"}}&#9745;{{else}}&#9744;{{end}} {{.Description}}{{if and .Status.Name (ne .Status.Name "DONE")}} {{template "status" .Status}}{{end}}</li>
+{{end}}</ul>
+<h2>Notes</h2>
+<pre>{{range .Notes}}{{.}}
+{{end}}</pre>
+<h2>Log</h2>
+<pre>{{range .Log}}{{.}}
+{{end}}</pre>
+<h2>TODO</h2>
+{{template "tasks" .Tasks.Tasks}}
+{{end}}
+{{template "bottom"}}{{end}}
+
+{{define "task"}}{{template "top" (printf "%s - %s" .Task.Name .Task.Description)}}
+<p>{{template "status" .Task.Status}} as of <a href="{{dayPage .LastSeen}}">{{.LastSeen.Format "Jan _2, 2006"}}</a></p>
+{{with .Task.Comments}}<ul>
+{{range .}}<li>{{.}}</li>
+{{end}}</ul>{{end}}
+<h2>Timeline</h2>
+<table class="timeline">
+{{range .Events}}<tr><td><a href="{{dayPage .Date}}">{{.Date.Format "Jan _2, 2006"}}</a></td><td class="marker">{{.Marker}}</td><td>{{.Text}}</td></tr>
+{{end}}</table>
+{{template "bottom"}}{{end}}
+
+{{define "search"}}{{template "top" "Search"}}
+<input id="q" type="search" placeholder="Search tasks and days" autofocus>
+<ul id="results"></ul>
+<script>
+var searchIndex = {{.}};
+var q = document.getElementById("q");
+var results = document.getElementById("results");
+function search() {
+	var terms = q.value.toLowerCase().split(/\s+/).filter(Boolean);
+	results.innerHTML = "";
+	if (!terms.length) {
+		return;
+	}
+	searchIndex.forEach(function(entry) {
+		var text = (entry.title + "\n" + entry.text).toLowerCase();
+		if (!terms.every(function(t) { return text.indexOf(t) >= 0; })) {
+			return;
+		}
+		var li = document.createElement("li");
+		var a = document.createElement("a");
+		a.href = entry.url;
+		a.textContent = entry.title;
+		li.appendChild(a);
+		results.appendChild(li);
+	});
+}
+q.addEventListener("input", search);
+var param = new URLSearchParams(location.search).get("q");
+if (param) {
+	q.value = param;
+	search();
+}
+</script>
+{{template "bottom"}}{{end}}
+`
+
+const siteStyle = `body { font-family: sans-serif; max-width: 60em; margin: 1em auto; padding: 0 1em; }
+nav a { margin-right: 1em; }
+table { border-collapse: collapse; width: 100%; }
+td { padding: 0.2em 0.5em; vertical-align: top; }
+tr.comment td { color: #666; font-size: 90%; }
+ul.startup { list-style: none; padding-left: 0; }
+pre { background: #f6f6f6; padding: 0.5em; white-space: pre-wrap; }
+td.marker { font-family: monospace; }
+#q { width: 100%; font-size: 120%; }
+.status { font-family: monospace; }
+.status-new { color: #8250df; }
+.status-in-progress, .status-inprogress { color: #1a7f37; }
+.status-ready { color: #0969da; }
+.status-review, .status-waiting, .status-responded { color: #9a6700; }
+.status-stale { color: #cf222e; }
+.status-hold, .status-done { color: #6e7781; }
+`
+
+var nonPageRe = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// taskPage returns the file name of the page for the task with the given name.
+func taskPage(name string) string {
+	return "task-" + nonPageRe.ReplaceAllString(name, "_") + ".html"
+}
+
+// dayPage returns the file name of the page for the today file for date.
+func dayPage(date time.Time) string {
+	return "day-" + date.Format("2006-01-02") + ".html"
+}
+
+// statusClass returns the CSS class for a status name, e.g. "status-in-progress".
+func statusClass(name string) string {
+	class := strings.Trim(nonPageRe.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if class == "" {
+		class = "new"
+	}
+	return "status-" + class
+}
+
+// siteBucket is a group of open tasks on the index page.
+type siteBucket struct {
+	Name  string
+	Tasks []*today.Task
+}
+
+// siteEvent is one line of a task's timeline.
+type siteEvent struct {
+	Date   time.Time
+	Marker string
+	Text   string
+}
+
+// searchEntry is an entry in the search index. Text is everything the entry can be found by.
+type searchEntry struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+	Text  string `json:"text"`
+}
+
+// siteCmd renders every today file in dir as a static HTML site: a page per day, a page per task
+// with its timeline, an index of the open tasks grouped by priority, and a search page backed by a
+// JSON index.
+func siteCmd(dir string, args []string) error {
+	fs := flag.NewFlagSet("site", flag.ExitOnError)
+	out := fs.String("o", "site", "The directory to write the site to.")
+	fs.Parse(args)
+
+	days, err := loadDays(dir)
+	if err != nil {
+		return err
+	}
+	tmpl, err := template.New("site").Funcs(template.FuncMap{
+		"dayPage":     dayPage,
+		"taskPage":    taskPage,
+		"statusClass": statusClass,
+		"statusText":  statusText,
+	}).Parse(siteTemplates)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		return err
+	}
+	render := func(file, name string, data interface{}) error {
+		f, err := os.Create(path.Join(*out, file))
+		if err != nil {
+			return err
+		}
+		if err := tmpl.ExecuteTemplate(f, name, data); err != nil {
+			f.Close()
+			return fmt.Errorf("rendering %s: %v", file, err)
+		}
+		return f.Close()
+	}
+
+	var index []searchEntry
+	var names []string
+	seen := make(map[string]bool)
+	for i, day := range days {
+		data := struct {
+			Day        today.Day
+			Prev, Next *today.Day
+		}{Day: day}
+		if i > 0 {
+			data.Prev = &days[i-1]
+		}
+		if i < len(days)-1 {
+			data.Next = &days[i+1]
+		}
+		page := dayPage(day.Date)
+		if err := render(page, "day", data); err != nil {
+			return err
+		}
+		index = append(index, searchEntry{
+			Title: day.Date.Format("Monday, Jan _2, 2006"),
+			URL:   page,
+			Text:  strings.Join(append(append([]string{}, day.Today.Notes...), day.Today.Log...), "\n"),
+		})
+		for _, task := range day.Today.Tasks.Tasks {
+			if task.Name != "" && !seen[task.Name] {
+				seen[task.Name] = true
+				names = append(names, task.Name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		events := today.TaskHistory(name, days)
+		var (
+			last     *today.Task
+			lastSeen today.Day
+		)
+		for _, day := range days {
+			if task := day.Today.Tasks.Find(name); task != nil {
+				last, lastSeen = task, day
+			}
+		}
+		var timeline []siteEvent
+		for _, e := range events {
+			ev := siteEvent{Date: e.Date}
+			switch e.Kind {
+			case today.Created:
+				ev.Marker, ev.Text = "+", "created ["+statusText(e.Status)+"]"
+			case today.StatusChanged:
+				ev.Marker, ev.Text = "~", "["+statusText(e.Previous)+"] → ["+statusText(e.Status)+"]"
+			case today.Completed:
+				ev.Marker, ev.Text = "*", "["+statusText(e.Status)+"]"
+			case today.CommentAdded:
+				ev.Marker, ev.Text = "+", e.Comment
+			case today.CommentRemoved:
+				ev.Marker, ev.Text = "-", e.Comment
+			case today.Removed:
+				ev.Marker, ev.Text = "-", "removed ["+statusText(e.Previous)+"]"
+			}
+			timeline = append(timeline, ev)
+		}
+		data := struct {
+			Task     *today.Task
+			LastSeen time.Time
+			Events   []siteEvent
+		}{last, lastSeen.Date, timeline}
+		if err := render(taskPage(name), "task", data); err != nil {
+			return err
+		}
+		index = append(index, searchEntry{
+			Title: name + " - " + last.Description,
+			URL:   taskPage(name),
+			Text:  last.Status.Name + "\n" + last.Status.Comment + "\n" + strings.Join(last.Comments, "\n"),
+		})
+	}
+
+	if len(days) > 0 {
+		latest := days[len(days)-1]
+		latest.Today.Sort()
+		var buckets []siteBucket
+		for _, task := range latest.Today.Tasks.Tasks {
+			if task.Status.Name == "DONE" {
+				continue
+			}
+			name := bucketName(task.Status.Priority())
+			if len(buckets) == 0 || buckets[len(buckets)-1].Name != name {
+				buckets = append(buckets, siteBucket{Name: name})
+			}
+			buckets[len(buckets)-1].Tasks = append(buckets[len(buckets)-1].Tasks, task)
+		}
+		data := struct {
+			Date    time.Time
+			Buckets []siteBucket
+		}{latest.Date, buckets}
+		if err := render("index.html", "index", data); err != nil {
+			return err
+		}
+	}
+
+	newestFirst := make([]today.Day, len(days))
+	for i, day := range days {
+		newestFirst[len(days)-1-i] = day
+	}
+	if err := render("days.html", "days", newestFirst); err != nil {
+		return err
+	}
+
+	js, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path.Join(*out, "search.json"), js, 0644); err != nil {
+		return err
+	}
+	// The index is also built into the search page, so that search works when the site is opened
+	// straight from disk, where browsers won't load search.json.
+	if err := render("search.html", "search", template.JS(js)); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path.Join(*out, "style.css"), []byte(siteStyle), 0644)
+}
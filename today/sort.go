@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/knusbaum/today"
+)
+
+// sortCmd sorts the current today file without updating it. With -explain, it instead prints each
+// task's rank and the reason for it, leaving the file alone.
+func sortCmd(dir string, args []string) error {
+	fs := flag.NewFlagSet("sort", flag.ExitOnError)
+	explain := fs.Bool("explain", false, "Print each task with its rank and the reason for it rather than sorting the file.")
+	fs.Parse(args)
+
+	if !*explain {
+		return updateToday(dir, func(t *today.Today) error {
+			t.Sort()
+			return nil
+		})
+	}
+
+	t, err := readToday(dir)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "RANK\tTASK\tDESCRIPTION\tREASON\n")
+	for _, r := range t.Tasks.Explain() {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", r.Rank, r.Task.Name, r.Task.Description, r.Reason)
+	}
+	return w.Flush()
+}
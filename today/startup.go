@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"text/tabwriter"
+	"time"
+
+	"github.com/knusbaum/today"
+)
+
+// startupHistoryFile records the status of each Startup item at the end of each day, since Clear
+// wipes them during generation.
+const startupHistoryFile = "startup.history"
+
+// startupHistory returns the recorded Startup statuses in dir. If nothing has been recorded yet, the
+// history is rebuilt from the today files before the current day and saved. The caller should hold
+// the lock on dir. (See lockDir)
+func startupHistory(dir string) ([]today.StartupRecord, error) {
+	name := path.Join(dir, startupHistoryFile)
+	f, err := os.Open(name)
+	if err == nil {
+		defer f.Close()
+		return today.ParseStartupRecords(f)
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	days, err := loadDays(dir)
+	if err != nil && err != errNoTodayFiles {
+		return nil, err
+	}
+	y, m, d := time.Now().Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	var records []today.StartupRecord
+	for _, day := range days {
+		if !day.Date.Before(midnight) {
+			continue
+		}
+		records = append(records, day.Today.Startup.Record(day.Date)...)
+	}
+
+	return records, writeFileAtomic(name, func(w io.Writer) error {
+		return today.WriteStartupRecords(w, records)
+	})
+}
+
+// recordStartup appends the statuses of the Startup items in l at the end of date to the history in
+// dir. The caller should hold the lock on dir.
+func recordStartup(dir string, l today.List, date time.Time) error {
+	// Make sure earlier days are recorded before adding this one.
+	if _, err := startupHistory(dir); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path.Join(dir, startupHistoryFile), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return today.WriteStartupRecords(f, l.Record(date))
+}
+
+// startupCmd runs commands dealing with the Startup section.
+func startupCmd(dir string, args []string) error {
+	if len(args) == 0 || args[0] != "stats" {
+		return fmt.Errorf("usage: today startup stats [-days N]")
+	}
+	fs := flag.NewFlagSet("startup stats", flag.ExitOnError)
+	n := fs.Int("days", 30, "The number of days to compute statistics over.")
+	fs.Parse(args[1:])
+
+	unlock, err := lockDir(dir)
+	if err != nil {
+		return err
+	}
+	records, err := startupHistory(dir)
+	unlock()
+	if err != nil {
+		return err
+	}
+	y, m, d := time.Now().Date()
+	since := time.Date(y, m, d-*n, 0, 0, 0, 0, time.Local)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "ITEM\tDONE\tRATE\tSTREAK\n")
+	for _, s := range today.StartupStats(records, since) {
+		fmt.Fprintf(w, "%s\t%d/%d\t%.0f%%\t%d\n", s.Description, s.Completed, s.Days, s.Rate()*100, s.Streak)
+	}
+	return w.Flush()
+}
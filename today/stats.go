@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/knusbaum/today"
+)
+
+// jsonTaskStats is the JSON form of today.TaskStats. Durations are given in days, which is easier
+// to chart than nanoseconds.
+type jsonTaskStats struct {
+	Name         string             `json:"name"`
+	Description  string             `json:"description"`
+	Status       string             `json:"status"`
+	Open         bool               `json:"open"`
+	FirstSeen    string             `json:"first_seen"`
+	LastSeen     string             `json:"last_seen"`
+	Completed    string             `json:"completed,omitempty"`
+	Removed      bool               `json:"removed,omitempty"`
+	AgeDays      float64            `json:"age_days"`
+	DaysInStatus map[string]float64 `json:"days_in_status"`
+}
+
+type jsonWeekCount struct {
+	Week string `json:"week"`
+	Done int    `json:"done"`
+}
+
+type jsonStats struct {
+	Tasks      []jsonTaskStats `json:"tasks"`
+	Throughput []jsonWeekCount `json:"throughput"`
+	Oldest     []string        `json:"oldest_open"`
+}
+
+func inDays(d time.Duration) float64 {
+	return d.Hours() / 24
+}
+
+// formatAge formats a duration in whole days, or hours if it is less than a day.
+func formatAge(d time.Duration) string {
+	if d < 24*time.Hour {
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(inDays(d)))
+}
+
+func formatInStatus(m map[string]time.Duration) string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s:%s", name, formatAge(m[name])))
+	}
+	return strings.Join(parts, " ")
+}
+
+// statsCmd prints task aging and throughput statistics computed from every today file.
+func statsCmd(dir string, args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the statistics as JSON.")
+	n := fs.Int("n", 10, "The number of oldest open tasks to list.")
+	fs.Parse(args)
+
+	ds, err := loadDays(dir)
+	if err != nil {
+		return err
+	}
+	stats := today.ComputeStats(ds, time.Now())
+	oldest := stats.Oldest(*n)
+
+	if *asJSON {
+		js := jsonStats{
+			Tasks:      make([]jsonTaskStats, 0, len(stats.Tasks)),
+			Throughput: make([]jsonWeekCount, 0, len(stats.Throughput)),
+			Oldest:     make([]string, 0, len(oldest)),
+		}
+		for _, ts := range stats.Tasks {
+			jt := jsonTaskStats{
+				Name:         ts.Name,
+				Description:  ts.Description,
+				Status:       ts.Status.Name,
+				Open:         ts.Open(),
+				Removed:      ts.Removed,
+				FirstSeen:    ts.FirstSeen.Format("2006-01-02"),
+				LastSeen:     ts.LastSeen.Format("2006-01-02"),
+				AgeDays:      inDays(ts.Age),
+				DaysInStatus: make(map[string]float64),
+			}
+			if !ts.Open() {
+				jt.Completed = ts.Completed.Format("2006-01-02")
+			}
+			for name, d := range ts.InStatus {
+				jt.DaysInStatus[name] = inDays(d)
+			}
+			js.Tasks = append(js.Tasks, jt)
+		}
+		for _, wc := range stats.Throughput {
+			js.Throughput = append(js.Throughput, jsonWeekCount{Week: wc.Week.Format("2006-01-02"), Done: wc.Done})
+		}
+		for _, ts := range oldest {
+			js.Oldest = append(js.Oldest, ts.Name)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(js)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "TASK\tSTATUS\tAGE\tFIRST SEEN\tTIME IN STATUS\n")
+	for _, ts := range stats.Tasks {
+		status := ts.Status.Name
+		if ts.Removed {
+			status = "(removed)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", ts.Name, status, formatAge(ts.Age),
+			ts.FirstSeen.Format("Jan _2, 2006"), formatInStatus(ts.InStatus))
+	}
+	w.Flush()
+
+	fmt.Printf("\nDONE per week:\n")
+	fmt.Fprintf(w, "WEEK OF\tDONE\n")
+	for _, wc := range stats.Throughput {
+		fmt.Fprintf(w, "%s\t%d\n", wc.Week.Format("Jan _2, 2006"), wc.Done)
+	}
+	w.Flush()
+
+	fmt.Printf("\nOldest open tasks:\n")
+	fmt.Fprintf(w, "TASK\tAGE\tSTATUS\tDESCRIPTION\n")
+	for _, ts := range oldest {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", ts.Name, formatAge(ts.Age), ts.Status.Name, ts.Description)
+	}
+	return w.Flush()
+}
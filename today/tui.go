@@ -0,0 +1,527 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/knusbaum/today"
+	"golang.org/x/term"
+)
+
+type pane int
+
+const (
+	tasksPane pane = iota
+	startupPane
+)
+
+// logHeight is the number of Log lines shown at the bottom of the screen.
+const logHeight = 6
+
+// statusKeys maps keys to the statuses they apply. The upper case version of each key applies the
+// same status, but first prompts for a status comment.
+var statusKeys = map[string]string{
+	"u": "?",
+	"p": "IN PROGRESS",
+	"r": "READY",
+	"v": "REVIEW",
+	"w": "WAITING",
+	"e": "RESPONDED",
+	"s": "STALE",
+	"d": "DONE",
+}
+
+const tuiHelp = "tab:pane j/k:move p:progress r:ready v:review w:waiting e:responded s:stale d:done u:? (shift: +comment) h:hold c:comment a:add q:quit"
+
+// A segment is a run of text drawn in a single style.
+type segment struct {
+	text  string
+	style string
+}
+
+type tui struct {
+	dir    string
+	t      *today.Today
+	in     *bufio.Reader
+	out    *bufio.Writer
+	focus  pane
+	task   int
+	item   int
+	scroll int
+	msg    string
+}
+
+// tuiCmd runs a full-screen terminal interface for browsing and editing the current today file.
+func tuiCmd(dir string, args []string) error {
+	fs := flag.NewFlagSet("tui", flag.ExitOnError)
+	fs.Parse(args)
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return fmt.Errorf("standard input is not a terminal")
+	}
+	if _, err := rolloverToday(dir); err != nil {
+		return err
+	}
+	t, err := readToday(dir)
+	if err != nil {
+		return err
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	u := &tui{dir: dir, t: t, in: bufio.NewReader(os.Stdin), out: bufio.NewWriter(os.Stdout)}
+	// Switch to the alternate screen and hide the cursor while running.
+	u.out.WriteString("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		u.out.WriteString("\x1b[?25h\x1b[?1049l")
+		u.out.Flush()
+	}()
+	return u.run()
+}
+
+func (u *tui) run() error {
+	for {
+		u.draw()
+		key, err := u.readKey()
+		if err != nil {
+			return err
+		}
+		u.msg = ""
+		switch key {
+		case "q", "ctrl-c":
+			return nil
+		case "tab":
+			if u.focus == tasksPane {
+				u.focus = startupPane
+			} else {
+				u.focus = tasksPane
+			}
+		case "j", "down":
+			u.move(1)
+		case "k", "up":
+			u.move(-1)
+		case "a":
+			if desc, ok := u.prompt("New task"); ok && desc != "" {
+				task := &today.Task{Description: desc}
+				u.commit(func(t *today.Today) error {
+					t.Tasks.Tasks = append(t.Tasks.Tasks, task)
+					return nil
+				}, nil)
+				u.selectTask(task)
+			}
+		default:
+			if u.focus == tasksPane {
+				u.taskKey(key)
+			} else {
+				u.startupKey(key)
+			}
+		}
+	}
+}
+
+func (u *tui) move(n int) {
+	if u.focus == tasksPane {
+		u.task = clamp(u.task+n, len(u.t.Tasks.Tasks))
+	} else {
+		u.item = clamp(u.item+n, len(u.t.Startup))
+	}
+}
+
+func clamp(i, n int) int {
+	if i >= n {
+		i = n - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	return i
+}
+
+func (u *tui) selectedTask() *today.Task {
+	if u.task < len(u.t.Tasks.Tasks) {
+		return u.t.Tasks.Tasks[u.task]
+	}
+	return nil
+}
+
+func (u *tui) selectTask(task *today.Task) {
+	for i := range u.t.Tasks.Tasks {
+		if u.t.Tasks.Tasks[i] == task {
+			u.task = i
+			return
+		}
+	}
+}
+
+// findTask returns the task in t that task, from the file as the tui last read it, refers to.
+func findTask(t *today.Today, task *today.Task) (*today.Task, error) {
+	if task.Name != "" {
+		if found := t.Tasks.Find(task.Name); found != nil {
+			return found, nil
+		}
+		return nil, fmt.Errorf("%s is no longer in the today file", task.Name)
+	}
+	for _, found := range t.Tasks.Tasks {
+		if found.Name == "" && found.Description == task.Description {
+			return found, nil
+		}
+	}
+	return nil, fmt.Errorf("%q is no longer in the today file", task.Description)
+}
+
+// editTask commits edit, applied to the selected task, and keeps the task selected.
+func (u *tui) editTask(edit func(task *today.Task), after func(task *today.Task)) {
+	var task *today.Task
+	u.commit(func(t *today.Today) error {
+		var err error
+		if task, err = findTask(t, u.selectedTask()); err != nil {
+			return err
+		}
+		edit(task)
+		return nil
+	}, func() {
+		if after != nil {
+			after(task)
+		}
+	})
+	u.selectTask(task)
+}
+
+func (u *tui) taskKey(key string) {
+	if u.selectedTask() == nil {
+		return
+	}
+	switch key {
+	case "c":
+		if c, ok := u.prompt("Comment"); ok && c != "" {
+			u.editTask(func(task *today.Task) {
+				task.Comments = append(task.Comments, c)
+			}, nil)
+		}
+	case "h":
+		in, ok := u.prompt("Hold until (Jan 2, 2006 / 2006-01-02 / +days)")
+		if !ok {
+			return
+		}
+		date, err := parseHoldDate(in, time.Now())
+		if err != nil {
+			u.msg = err.Error()
+			return
+		}
+		// Update dates the new status with today's date and logs it. The hold date replaces it.
+		u.editTask(func(task *today.Task) {
+			task.Status = today.Status{Name: "HOLD"}
+		}, func(task *today.Task) {
+			task.Status.Date = date
+		})
+	default:
+		name, comment, ok := u.statusForKey(key)
+		if !ok {
+			return
+		}
+		u.editTask(func(task *today.Task) {
+			task.Status = today.Status{Name: name, Comment: comment}
+		}, nil)
+	}
+}
+
+func (u *tui) startupKey(key string) {
+	if u.item >= len(u.t.Startup) {
+		return
+	}
+	selected := u.t.Startup[u.item]
+	var status today.Status
+	if key == " " {
+		if selected.Status.Name != "DONE" {
+			status = today.Status{Name: "DONE"}
+		}
+	} else {
+		name, comment, ok := u.statusForKey(key)
+		if !ok {
+			return
+		}
+		status = today.Status{Name: name, Comment: comment}
+	}
+	u.commit(func(t *today.Today) error {
+		if u.item >= len(t.Startup) || t.Startup[u.item].Description != selected.Description {
+			return fmt.Errorf("%q is no longer in the today file", selected.Description)
+		}
+		t.Startup[u.item].Status = status
+		return nil
+	}, nil)
+}
+
+// statusForKey returns the status a key applies, prompting for a comment for upper case keys.
+func (u *tui) statusForKey(key string) (name, comment string, ok bool) {
+	name, ok = statusKeys[strings.ToLower(key)]
+	if !ok {
+		return "", "", false
+	}
+	if key != strings.ToLower(key) {
+		comment, ok = u.prompt(name + " comment")
+	}
+	return name, comment, ok
+}
+
+// commit applies change to the current today file, read afresh under the lock so that edits made
+// by other commands since the tui last read it are kept, then updates, sorts and writes it and
+// shows the result. If after is not nil, it is called after Update and before Sort.
+func (u *tui) commit(change func(t *today.Today) error, after func()) {
+	var updated *today.Today
+	err := updateToday(u.dir, func(t *today.Today) error {
+		if err := change(t); err != nil {
+			return err
+		}
+		t.Update()
+		if after != nil {
+			after()
+		}
+		t.Sort()
+		updated = t
+		return nil
+	})
+	if err != nil {
+		u.msg = fmt.Sprintf("Failed to write today: %s", err)
+		// Show the file as it is now, since it may have changed.
+		if updated, err = readToday(u.dir); err != nil {
+			return
+		}
+	}
+	u.t = updated
+	u.move(0)
+}
+
+// parseHoldDate parses the date a task should be held until. It accepts the date format used in
+// today files, an ISO date, or a number of days from now such as "+3".
+func parseHoldDate(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "+") {
+		n, err := strconv.Atoi(strings.TrimSuffix(s[1:], "d"))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("bad number of days %q", s)
+		}
+		y, m, d := now.Date()
+		return time.Date(y, m, d+n, 0, 0, 0, 0, time.Local), nil
+	}
+	for _, layout := range []string{"Jan _2, 2006", "Jan 2, 2006", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse date %q", s)
+}
+
+func (u *tui) readKey() (string, error) {
+	r, _, err := u.in.ReadRune()
+	if err != nil {
+		return "", err
+	}
+	switch r {
+	case 0x1b:
+		// An escape sequence arrives all at once. A lone escape is the escape key.
+		if u.in.Buffered() < 2 {
+			return "esc", nil
+		}
+		if b, _ := u.in.Peek(1); b[0] != '[' {
+			return "esc", nil
+		}
+		u.in.ReadByte()
+		c, _ := u.in.ReadByte()
+		switch c {
+		case 'A':
+			return "up", nil
+		case 'B':
+			return "down", nil
+		}
+		return "", nil
+	case '\t':
+		return "tab", nil
+	case '\r', '\n':
+		return "enter", nil
+	case 0x7f, 0x08:
+		return "backspace", nil
+	case 0x03:
+		return "ctrl-c", nil
+	}
+	return string(r), nil
+}
+
+// prompt reads a line of text on the bottom line of the screen. It returns false if the prompt was
+// cancelled with escape.
+func (u *tui) prompt(label string) (string, bool) {
+	var text []rune
+	for {
+		u.msg = label + ": " + string(text) + "_"
+		u.draw()
+		key, err := u.readKey()
+		if err != nil {
+			return "", false
+		}
+		switch key {
+		case "enter":
+			u.msg = ""
+			return strings.TrimSpace(string(text)), true
+		case "esc", "ctrl-c":
+			u.msg = ""
+			return "", false
+		case "backspace":
+			if len(text) > 0 {
+				text = text[:len(text)-1]
+			}
+		default:
+			if r, _ := utf8.DecodeRuneInString(key); len(key) == utf8.RuneLen(r) && unicode.IsPrint(r) {
+				text = append(text, r)
+			}
+		}
+	}
+}
+
+func statusSegment(s today.Status) segment {
+	var parts []string
+	if s.Name != "" {
+		parts = append(parts, s.Name)
+	}
+	if s.Comment != "" {
+		parts = append(parts, s.Comment)
+	}
+	if !s.Date.IsZero() {
+		parts = append(parts, s.Date.Format("Jan _2, 2006"))
+	}
+	if len(parts) == 0 {
+		return segment{}
+	}
+	return segment{text: "[" + strings.Join(parts, " - ") + "]", style: statusColor(s.Name)}
+}
+
+func header(title string, focused bool) []segment {
+	if focused {
+		return []segment{{text: title, style: ansiBold + ansiReverse}}
+	}
+	return []segment{{text: title, style: ansiBold}}
+}
+
+func (u *tui) draw() {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		width, height = 80, 24
+	}
+
+	var top [][]segment
+	top = append(top, header("Morning Start Up", u.focus == startupPane))
+	for i, item := range u.t.Startup {
+		check := "[ ] "
+		if item.Status.Name == "DONE" {
+			check = "[x] "
+		}
+		style := ""
+		if u.focus == startupPane && i == u.item {
+			style = ansiReverse
+		}
+		top = append(top, []segment{
+			{text: check + strconv.Itoa(i+1) + ". " + item.Description, style: style},
+			{text: " "},
+			statusSegment(item.Status),
+		})
+	}
+	top = append(top, nil, header("TODO", u.focus == tasksPane))
+
+	var bottom [][]segment
+	bottom = append(bottom, nil, header("Log", false))
+	logLines := u.t.Log
+	if len(logLines) > logHeight {
+		logLines = logLines[len(logLines)-logHeight:]
+	}
+	for _, l := range logLines {
+		bottom = append(bottom, []segment{{text: l}})
+	}
+	bottom = append(bottom, []segment{{text: tuiHelp, style: ansiDim}}, []segment{{text: u.msg}})
+
+	// The tasks get whatever room is left. The selected task's comments are shown beneath it.
+	var tasks [][]segment
+	selectedLine := 0
+	for i, task := range u.t.Tasks.Tasks {
+		style := ""
+		if u.focus == tasksPane && i == u.task {
+			style = ansiReverse
+			selectedLine = len(tasks)
+		}
+		tasks = append(tasks, []segment{
+			{text: task.Name + " - " + task.Description, style: style},
+			{text: " "},
+			statusSegment(task.Status),
+		})
+		if i == u.task {
+			for _, c := range task.Comments {
+				tasks = append(tasks, []segment{{text: "    " + c, style: ansiDim}})
+			}
+		}
+	}
+	room := height - len(top) - len(bottom)
+	if room < 1 {
+		room = 1
+	}
+	if selectedLine < u.scroll {
+		u.scroll = selectedLine
+	}
+	if selectedLine >= u.scroll+room {
+		u.scroll = selectedLine - room + 1
+	}
+	if u.scroll > len(tasks) {
+		u.scroll = 0
+	}
+	end := u.scroll + room
+	if end > len(tasks) {
+		end = len(tasks)
+	}
+
+	lines := append(top, tasks[u.scroll:end]...)
+	for len(lines) < height-len(bottom) {
+		lines = append(lines, nil)
+	}
+	lines = append(lines, bottom...)
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+
+	u.out.WriteString("\x1b[H\x1b[2J")
+	for i, l := range lines {
+		u.out.WriteString(render(l, width))
+		if i < len(lines)-1 {
+			u.out.WriteString("\r\n")
+		}
+	}
+	u.out.Flush()
+}
+
+// render draws a line of segments, truncated to width columns.
+func render(line []segment, width int) string {
+	var b strings.Builder
+	for _, seg := range line {
+		if width <= 0 {
+			break
+		}
+		text := seg.text
+		if n := utf8.RuneCountInString(text); n > width {
+			text = string([]rune(text)[:width])
+		}
+		width -= utf8.RuneCountInString(text)
+		if seg.style != "" {
+			b.WriteString(seg.style + text + ansiReset)
+		} else {
+			b.WriteString(text)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"embed"
+	"flag"
+	iofs "io/fs"
+	"log"
+	"net/http"
+)
+
+// webAssets holds the pages and scripts of the web UI, so that the today binary doesn't need
+// anything else to serve it.
+//
+//go:embed web
+var webAssets embed.FS
+
+// webCmd serves a web UI for the today file in dir: a board of the tasks by status and the Startup
+// checklist. The UI makes its changes through the API of today serve, which it serves under /api.
+func webCmd(dir string, args []string) error {
+	fs := flag.NewFlagSet("web", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:8080", "The address to listen on.")
+	fs.Parse(args)
+
+	assets, err := iofs.Sub(webAssets, "web")
+	if err != nil {
+		return err
+	}
+	s := &server{dir: dir, addr: *addr}
+	mux := http.NewServeMux()
+	mux.Handle("/api/", http.StripPrefix("/api", s.handler()))
+	mux.Handle("/", http.FileServer(http.FS(assets)))
+	log.Printf("Serving %s on http://%s", dir, *addr)
+	return http.ListenAndServe(*addr, mux)
+}
@@ -0,0 +1,178 @@
+// The columns of the board, in sort order. A task goes in the column for its status. Tasks with
+// other statuses get columns of their own after these.
+var columns = ["?", "IN PROGRESS", "READY", "REVIEW", "WAITING", "RESPONDED", "STALE", "HOLD", "DONE"];
+
+function api(method, path, body) {
+	var opts = {method: method, headers: {"Content-Type": "application/json"}};
+	if (body !== undefined) {
+		opts.body = JSON.stringify(body);
+	}
+	return fetch("api" + path, opts).then(function(resp) {
+		return resp.json().then(function(v) {
+			if (!resp.ok) {
+				throw new Error(v.error || resp.statusText);
+			}
+			document.getElementById("error").textContent = "";
+			return v;
+		});
+	}).catch(function(err) {
+		document.getElementById("error").textContent = err.message;
+		throw err;
+	});
+}
+
+function el(tag, className, text) {
+	var e = document.createElement(tag);
+	if (className) {
+		e.className = className;
+	}
+	if (text) {
+		e.textContent = text;
+	}
+	return e;
+}
+
+function statusText(status) {
+	var s = status.name || "?";
+	if (status.comment) {
+		s += " - " + status.comment;
+	}
+	if (status.date) {
+		s += " - " + status.date;
+	}
+	return s;
+}
+
+// editing is true while a comment is being edited, so that a refresh doesn't throw the edit away.
+var editing = false;
+
+function renderStartup(items) {
+	var list = document.getElementById("checklist");
+	list.innerHTML = "";
+	items.forEach(function(item) {
+		var li = el("li", item.status.name === "DONE" ? "done" : "");
+		var label = el("label");
+		var box = el("input");
+		box.type = "checkbox";
+		box.checked = item.status.name === "DONE";
+		box.addEventListener("change", function() {
+			api("PATCH", "/startup/" + item.number, {status: box.checked ? "DONE" : "", status_comment: ""}).then(refresh);
+		});
+		label.appendChild(box);
+		label.appendChild(document.createTextNode(" " + item.description));
+		li.appendChild(label);
+		list.appendChild(li);
+	});
+}
+
+function editComments(task, ul) {
+	editing = true;
+	var area = el("textarea");
+	area.rows = Math.max(task.comments.length + 1, 2);
+	area.value = task.comments.join("\n");
+	ul.replaceWith(area);
+	area.focus();
+	area.addEventListener("blur", function() {
+		editing = false;
+		var comments = area.value.split("\n").map(function(c) { return c.trim(); }).filter(Boolean);
+		api("PATCH", "/tasks/" + encodeURIComponent(task.name), {comments: comments}).then(refresh, refresh);
+	});
+}
+
+function card(task) {
+	var c = el("div", "card");
+	c.draggable = true;
+	c.addEventListener("dragstart", function(ev) {
+		ev.dataTransfer.setData("text/plain", task.name);
+	});
+	c.appendChild(el("div", "name", task.name));
+	c.appendChild(el("div", "description", task.description));
+	c.appendChild(el("div", "status", statusText(task.status)));
+	if (task.resurfaced) {
+		c.appendChild(el("div", "resurfaced", task.resurfaced));
+	}
+	var ul = el("ul", "comments");
+	task.comments.forEach(function(comment) {
+		ul.appendChild(el("li", "", comment));
+	});
+	ul.title = "Click to edit comments";
+	ul.addEventListener("click", function() { editComments(task, ul); });
+	c.appendChild(ul);
+	return c;
+}
+
+function column(name, tasks) {
+	var col = el("div", "column");
+	col.appendChild(el("h3", "", name + " (" + tasks.length + ")"));
+	tasks.forEach(function(task) { col.appendChild(card(task)); });
+	col.addEventListener("dragover", function(ev) {
+		ev.preventDefault();
+		col.classList.add("over");
+	});
+	col.addEventListener("dragleave", function() { col.classList.remove("over"); });
+	col.addEventListener("drop", function(ev) {
+		ev.preventDefault();
+		col.classList.remove("over");
+		var taskName = ev.dataTransfer.getData("text/plain");
+		var task = tasks.filter(function(t) { return t.name === taskName; })[0];
+		if (task) {
+			return;
+		}
+		// The new status has no date, so the server dates it and logs the move, just as if the
+		// status had been changed in the today file. A HOLD needs the date to hold the task until,
+		// or it would be released right away.
+		var change = {status: name, status_comment: ""};
+		if (name === "HOLD") {
+			var until = prompt("Hold " + taskName + " until (Jan 2, 2006, 2006-01-02 or +days)", "+1");
+			if (!until) {
+				return;
+			}
+			change = {hold_until: until, status_comment: ""};
+		}
+		api("PATCH", "/tasks/" + encodeURIComponent(taskName), change).then(refresh);
+	});
+	return col;
+}
+
+function renderTasks(tasks) {
+	var names = columns.slice();
+	var byStatus = {};
+	tasks.forEach(function(task) {
+		var name = task.status.name || "?";
+		if (names.indexOf(name) < 0) {
+			names.push(name);
+		}
+		(byStatus[name] = byStatus[name] || []).push(task);
+	});
+	var board = document.getElementById("board");
+	board.innerHTML = "";
+	names.forEach(function(name) {
+		board.appendChild(column(name, byStatus[name] || []));
+	});
+}
+
+function refresh() {
+	if (editing) {
+		return;
+	}
+	api("GET", "/startup").then(renderStartup);
+	api("GET", "/tasks").then(renderTasks);
+}
+
+document.getElementById("update").addEventListener("click", function() {
+	api("POST", "/update").then(refresh);
+});
+
+document.getElementById("add").addEventListener("submit", function(ev) {
+	ev.preventDefault();
+	var input = document.getElementById("add-description");
+	api("POST", "/tasks", {description: input.value}).then(function() {
+		input.value = "";
+		refresh();
+	});
+});
+
+// Pick up changes made to the today file outside of the browser.
+window.addEventListener("focus", refresh);
+setInterval(refresh, 10000);
+refresh();
@@ -273,9 +273,9 @@ Something else
 	result := b.String()
 
 	expected := `Morning Start Up:
-1. Do something 
-2. Do another thing 
-3. One more thing. 
+1. Do something
+2. Do another thing
+3. One more thing.
 
 Notes:
 Some note
@@ -283,7 +283,7 @@ Another Note
 One More Note
 
 Log:
-[0-9]+:[0-9]{2} - Moved TASK-1 \(Another Task\) to  IN PROGRESS
+[0-9]+:[0-9]{2}(AM|PM) - Moved TASK-1 \(Another Task\) to  IN PROGRESS
 
 TODO:
 TASK-0 - Some Task \[\? - [A-Za-z]{3} [0-9]+, [0-9]{4}\]
@@ -294,3 +294,107 @@ TASK-1 - Another Task \[IN PROGRESS - [A-Za-z]{3} [0-9]+, [0-9]{4}\]
 	assert.Regexp(t, expected, result)
 
 }
+
+func TestResurfaced(t *testing.T) {
+	now := time.Date(2020, 1, 10, 12, 0, 0, 0, time.Local)
+	for _, tc := range []struct {
+		status Status
+		reason string
+	}{
+		{Status{Name: "WAITING", Date: now.Add(-3 * 24 * time.Hour)}, "WAITING for 3 days (>24h)"},
+		{Status{Name: "REVIEW", Date: now.Add(-25 * time.Hour)}, "REVIEW for 1 day (>24h)"},
+		{Status{Name: "RESPONDED", Date: now.Add(-23 * time.Hour)}, ""},
+		{Status{Name: "STALE", Date: now.Add(-8 * 24 * time.Hour)}, "STALE for 8 days (>1 week)"},
+		{Status{Name: "STALE", Date: now.Add(-6 * 24 * time.Hour)}, ""},
+		{Status{Name: "HOLD", Date: time.Date(2020, 1, 9, 0, 0, 0, 0, time.Local)}, "HOLD until Jan  9, 2020 has arrived"},
+		{Status{Name: "HOLD", Date: now.Add(24 * time.Hour)}, ""},
+		{Status{Name: "IN PROGRESS", Date: now.Add(-30 * 24 * time.Hour)}, ""},
+	} {
+		assert.Equal(t, tc.reason, resurfaced(&tc.status, now), tc.status.Name)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	now := time.Now()
+	tasks := TaskList{
+		Tasks: []*Task{
+			&Task{Name: "TASK-1", Status: Status{Name: "READY", Date: now}},
+			&Task{Name: "TASK-2", Status: Status{Name: "WAITING", Date: now.Add(-3 * 24 * time.Hour)}},
+			&Task{Name: "TASK-3", Status: Status{Name: "?", Date: now}},
+			&Task{Name: "TASK-4", Status: Status{Name: "FROBNICATING", Date: now}},
+		},
+	}
+	rankings := tasks.Explain()
+	if !assert.Len(t, rankings, 4) {
+		return
+	}
+	assert.Equal(t, "TASK-2", rankings[0].Task.Name)
+	assert.Equal(t, 0, rankings[0].Rank)
+	assert.Equal(t, "WAITING for 3 days (>24h), resurfaced", rankings[0].Reason)
+	assert.Equal(t, "TASK-3", rankings[1].Task.Name)
+	assert.Equal(t, "no status yet", rankings[1].Reason)
+	assert.Equal(t, "TASK-4", rankings[2].Task.Name)
+	assert.Equal(t, "unknown status FROBNICATING, sorted with new tasks", rankings[2].Reason)
+	assert.Equal(t, "TASK-1", rankings[3].Task.Name)
+	assert.Equal(t, 2, rankings[3].Rank)
+	assert.Equal(t, "READY since "+now.Format("Jan _2, 2006"), rankings[3].Reason)
+
+	// Explain does not sort the TaskList itself.
+	assert.Equal(t, "TASK-1", tasks.Tasks[0].Name)
+}
+
+func TestUpdateListLog(t *testing.T) {
+	today := &Today{
+		Startup: []*ListItem{
+			&ListItem{Description: "Catch up on slack"},
+			&ListItem{Description: "Check the calendar", Status: Status{Name: "DONE"}},
+			&ListItem{Description: "Read the inbox", Status: Status{Name: "SKIPPED", Comment: "on vacation"}},
+			&ListItem{Description: "look at ticket tracker", Status: Status{Name: "DONE", Date: time.Now()}},
+		},
+	}
+	today.Update()
+	assert.True(t, today.Startup[0].Status.Date.IsZero())
+	assert.False(t, today.Startup[1].Status.Date.IsZero())
+	assert.False(t, today.Startup[2].Status.Date.IsZero())
+	if !assert.Len(t, today.Log, 2) {
+		return
+	}
+	assert.Regexp(t, `^[0-9]+:[0-9]{2}(AM|PM) - Completed startup item 2 \(Check the calendar\)$`, today.Log[0])
+	assert.Regexp(t, `^[0-9]+:[0-9]{2}(AM|PM) - Moved startup item 3 \(Read the inbox\) to SKIPPED \(on vacation\)$`, today.Log[1])
+}
+
+func TestFormat(t *testing.T) {
+	in := `Morning Start Up:
+3. Check the calendar   [DONE]
+Catch up on slack
+
+Notes:
+Log:
+9:12AM - Standup
+TODO:
+   Fix the sink    [READY]
+		  with a wrench
+JIRA-12 -   Write it up [WAITING   -   on Bob -   Jan  6, 2020]`
+	today, err := Parse(strings.NewReader(in))
+	if !assert.Nil(t, err) {
+		return
+	}
+	today.Format()
+	var b strings.Builder
+	assert.Nil(t, today.Write(&b))
+	assert.Equal(t, `Morning Start Up:
+1. Check the calendar [DONE]
+2. Catch up on slack
+
+Notes:
+
+Log:
+9:12AM - Standup
+
+TODO:
+Fix the sink [READY]
+	with a wrench
+JIRA-12 - Write it up [WAITING - on Bob - Jan  6, 2020]
+
+`, b.String())
+}
@@ -0,0 +1,172 @@
+package today
+
+import (
+	"bufio"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// todoTxtPriorities holds a status for each priorityOrder bucket between new tasks and "DONE", in
+// sort order. A task's todo.txt priority letter is the position of its status's bucket in this
+// list, so "IN PROGRESS" tasks are (A), "READY" tasks are (B), and "WAITING" and "RESPONDED"
+// tasks are both (D). New tasks, "DONE" tasks and tasks with unknown statuses get no priority.
+var todoTxtPriorities = []string{"IN PROGRESS", "READY", "REVIEW", "WAITING", "STALE", "HOLD"}
+
+const todoTxtDate = "2006-01-02"
+
+var (
+	todoTxtPriorityRe = regexp.MustCompile(`^\(([A-Z])\)$`)
+	todoTxtDateRe     = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}$`)
+	todoTxtExtRe      = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9_.-]*):([^[:space:]]+)$`)
+)
+
+// todoTxtPriority returns the priority letter for a status name, or 0 if it has none.
+func todoTxtPriority(name string) byte {
+	v, ok := priorityOrder[name]
+	if !ok {
+		return 0
+	}
+	for i, p := range todoTxtPriorities {
+		if priorityOrder[p] == v {
+			return 'A' + byte(i)
+		}
+	}
+	return 0
+}
+
+// todoTxtReserved holds the extension keys used for a task's own fields. Keyed comments using one
+// of these keys are written as plain comments instead.
+var todoTxtReserved = map[string]bool{"name": true, "status": true, "note": true, "comment": true, "t": true}
+
+// WriteTodoTxt writes the tasks of t to w in todo.txt format, one task per line:
+//   (A) 2020-01-05 Fix the frobnicator +backend @work name:JIRA-12 status:IN+PROGRESS note:pr+%233
+//   x 2020-01-07 Write docs name:TASK-4 comment:ask+Sam
+// The priority letter comes from the status's sort bucket (See todoTxtPriorities), and the date is
+// the status date. "DONE" tasks are marked complete with "x", and the date is when they were done.
+// "HOLD" tasks also get a threshold ("t:") of the date they are held until.
+//
+// The task name, status name and status comment are kept in the "name", "status" and "note"
+// extensions. Comments become "comment" extensions, except keyed comments such as "due: 2020-01-10",
+// which become extensions of their own ("due:2020-01-10"). Extension values are URL query escaped.
+// Any +project and @context tags are part of the description, and are left where they are.
+func (t *TaskList) WriteTodoTxt(w io.Writer) error {
+	wtr := bufio.NewWriter(w)
+	for _, task := range t.Tasks {
+		var fields []string
+		if task.Status.Name == "DONE" {
+			fields = append(fields, "x")
+		} else if p := todoTxtPriority(task.Status.Name); p != 0 {
+			fields = append(fields, "("+string(p)+")")
+		}
+		if !task.Status.Date.IsZero() {
+			fields = append(fields, task.Status.Date.Format(todoTxtDate))
+		}
+		if task.Description != "" {
+			fields = append(fields, task.Description)
+		}
+		ext := func(key, value string) {
+			fields = append(fields, key+":"+url.QueryEscape(value))
+		}
+		if task.Name != "" {
+			ext("name", task.Name)
+		}
+		if p := todoTxtPriority(task.Status.Name); (p == 0 || todoTxtPriorities[p-'A'] != task.Status.Name) &&
+			task.Status.Name != "DONE" && !task.Status.isUnknown() {
+			ext("status", task.Status.Name)
+		}
+		if task.Status.Comment != "" {
+			ext("note", task.Status.Comment)
+		}
+		if task.Status.Name == "HOLD" && !task.Status.Date.IsZero() {
+			ext("t", task.Status.Date.Format(todoTxtDate))
+		}
+		for _, c := range task.Comments {
+			if k, v, ok := splitNote(c); ok && !todoTxtReserved[k] {
+				ext(k, v)
+			} else {
+				ext("comment", c)
+			}
+		}
+		if _, err := wtr.WriteString(strings.Join(fields, " ") + "\n"); err != nil {
+			return err
+		}
+	}
+	return wtr.Flush()
+}
+
+// ParseTodoTxt parses tasks from todo.txt format, such as written by TaskList.WriteTodoTxt. Tasks
+// without a "status" extension get the status for their priority letter, or "?" if they have none.
+// Completed tasks are "DONE" as of their completion date. Extensions other than the ones
+// WriteTodoTxt uses for a task's fields become keyed comments. The tasks can be merged into a
+// TaskList with TaskList.Merge.
+func ParseTodoTxt(r io.Reader) ([]*Task, error) {
+	var tasks []*Task
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		task := &Task{}
+		if fields[0] == "x" {
+			task.Status.Name = "DONE"
+			fields = fields[1:]
+		} else if m := todoTxtPriorityRe.FindStringSubmatch(fields[0]); m != nil {
+			if i := int(m[1][0] - 'A'); i < len(todoTxtPriorities) {
+				task.Status.Name = todoTxtPriorities[i]
+			}
+			fields = fields[1:]
+		}
+		if len(fields) > 0 && todoTxtDateRe.MatchString(fields[0]) {
+			task.Status.Date, _ = time.ParseInLocation(todoTxtDate, fields[0], time.Local)
+			fields = fields[1:]
+			// A completed task may also have its creation date.
+			if task.Status.Name == "DONE" && len(fields) > 0 && todoTxtDateRe.MatchString(fields[0]) {
+				fields = fields[1:]
+			}
+		}
+
+		var description []string
+		for _, f := range fields {
+			m := todoTxtExtRe.FindStringSubmatch(f)
+			if m == nil || strings.HasPrefix(m[2], "//") {
+				description = append(description, f)
+				continue
+			}
+			value, err := url.QueryUnescape(m[2])
+			if err != nil {
+				value = m[2]
+			}
+			switch m[1] {
+			case "name":
+				task.Name = value
+			case "status":
+				task.Status.Name = value
+			case "note":
+				task.Status.Comment = value
+			case "t":
+				if task.Status.Name == "HOLD" {
+					if date, err := time.ParseInLocation(todoTxtDate, value, time.Local); err == nil {
+						task.Status.Date = date
+					}
+				}
+			case "comment":
+				task.Comments = append(task.Comments, value)
+			default:
+				task.Comments = append(task.Comments, m[1]+": "+value)
+			}
+		}
+		task.Description = strings.Join(description, " ")
+		if task.Status.Name == "" {
+			task.Status.Name = "?"
+		}
+		tasks = append(tasks, task)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
@@ -0,0 +1,50 @@
+package today
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTodoTxt(t *testing.T) {
+	d := time.Date(2020, 1, 5, 0, 0, 0, 0, time.Local)
+	list := TaskList{Tasks: []*Task{
+		{Name: "JIRA-12", Description: "Fix the frobnicator +backend @work", Status: Status{Name: "IN PROGRESS", Comment: "pr #3", Date: d}, Comments: []string{"step 1", "due: 2020-01-10"}},
+		{Name: "JIRA-13", Description: "Ask about it", Status: Status{Name: "RESPONDED", Date: d}},
+		{Name: "TASK-4", Description: "Write docs", Status: Status{Name: "DONE", Date: d.AddDate(0, 0, 2)}, Comments: []string{"note: a comment"}},
+		{Name: "TASK-5", Description: "Later", Status: Status{Name: "HOLD", Date: d.AddDate(0, 0, 7)}},
+		{Name: "TASK-6", Description: "New", Status: Status{Name: "?", Date: d}},
+		{Name: "TASK-7", Description: "Odd", Status: Status{Name: "BLOCKED", Date: d}},
+	}}
+
+	var b bytes.Buffer
+	assert.NoError(t, list.WriteTodoTxt(&b))
+	assert.Equal(t, `(A) 2020-01-05 Fix the frobnicator +backend @work name:JIRA-12 note:pr+%233 comment:step+1 due:2020-01-10
+(D) 2020-01-05 Ask about it name:JIRA-13 status:RESPONDED
+x 2020-01-07 Write docs name:TASK-4 comment:note%3A+a+comment
+(F) 2020-01-12 Later name:TASK-5 t:2020-01-12
+2020-01-05 New name:TASK-6
+2020-01-05 Odd name:TASK-7 status:BLOCKED
+`, b.String())
+
+	tasks, err := ParseTodoTxt(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, list.Tasks, tasks)
+}
+
+func TestParseTodoTxt(t *testing.T) {
+	tasks, err := ParseTodoTxt(strings.NewReader(`(B) Call Mom @phone see http://example.com
+x 2020-01-07 2020-01-01 Pay the bills
+
+(Z) Something else +home
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, []*Task{
+		{Description: "Call Mom @phone see http://example.com", Status: Status{Name: "READY"}},
+		{Description: "Pay the bills", Status: Status{Name: "DONE", Date: time.Date(2020, 1, 7, 0, 0, 0, 0, time.Local)}},
+		{Description: "Something else +home", Status: Status{Name: "?"}},
+	}, tasks)
+}
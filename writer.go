@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"strings"
 )
 
 func writeStatus(s *Status, w *bufio.Writer) error {
@@ -39,20 +40,30 @@ func writeStatus(s *Status, w *bufio.Writer) error {
 
 func writeTodo(t *Task, w *bufio.Writer) error {
 	if t.Name != "" {
-		_, err := w.WriteString(t.Name + " - ")
+		_, err := w.WriteString(t.Name + " -")
 		if err != nil {
 			return err
 		}
 	}
 
 	if t.Description != "" {
-		_, err := w.WriteString(t.Description + " ")
+		if t.Name != "" {
+			if _, err := w.WriteString(" "); err != nil {
+				return err
+			}
+		}
+		_, err := w.WriteString(t.Description)
 		if err != nil {
 			return err
 		}
 	}
 
 	if t.Status.Name != "" || t.Status.Comment != "" {
+		if t.Name != "" || t.Description != "" {
+			if _, err := w.WriteString(" "); err != nil {
+				return err
+			}
+		}
 		err := writeStatus(&t.Status, w)
 		if err != nil {
 			return err
@@ -80,11 +91,20 @@ func writeTodo(t *Task, w *bufio.Writer) error {
 }
 
 func writeListItem(item *ListItem, w *bufio.Writer) error {
-	_, err := w.WriteString(fmt.Sprintf("%d. %s ", item.number, item.Description))
+	_, err := w.WriteString(fmt.Sprintf("%d. %s", item.number, item.Description))
 	if err != nil {
 		return err
 	}
+	if item.Schedule != "" {
+		_, err = w.WriteString(" (" + item.Schedule + ")")
+		if err != nil {
+			return err
+		}
+	}
 	if item.Status.Name != "" || item.Status.Comment != "" {
+		if _, err := w.WriteString(" "); err != nil {
+			return err
+		}
 		err := writeStatus(&item.Status, w)
 		if err != nil {
 			return err
@@ -97,6 +117,17 @@ func writeListItem(item *ListItem, w *bufio.Writer) error {
 	return nil
 }
 
+// Write writes a List out to writer w in the same form as the Startup section of a today file.
+func (l List) Write(w *bufio.Writer) error {
+	for _, item := range l {
+		err := writeListItem(item, w)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (t *TaskList) Write(w *bufio.Writer) error {
 	for _, todo := range t.Tasks {
 		err := writeTodo(todo, w)
@@ -120,25 +151,29 @@ func (t *Today) Write(w io.Writer) error {
 	if err != nil {
 		return err
 	}
-	for _, item := range t.Startup {
-		err = writeListItem(item, wtr)
-		if err != nil {
-			return err
-		}
+	err = t.Startup.Write(wtr)
+	if err != nil {
+		return err
 	}
 
 	_, err = wtr.WriteString("\n" + notesLine + "\n")
 	if err != nil {
 		return err
 	}
-	for _, n := range t.Notes {
+	// Blank lines at the end of the Notes are left out in favor of the one written after them, as
+	// Parse leaves them out.
+	notes := t.Notes
+	for len(notes) > 0 && strings.TrimSpace(notes[len(notes)-1]) == "" {
+		notes = notes[:len(notes)-1]
+	}
+	for _, n := range notes {
 		_, err = wtr.WriteString(n + "\n")
 		if err != nil {
 			return err
 		}
 	}
 
-	_, err = wtr.WriteString(logLine + "\n")
+	_, err = wtr.WriteString("\n" + logLine + "\n")
 	if err != nil {
 		return err
 	}
@@ -23,10 +23,10 @@ func TestWriter(t *testing.T) {
 		w := bufio.NewWriter(&b)
 		today.Write(w)
 		expect := `Morning Start Up:
-1. Catch up on slack 
-2. Check the calendar 
-3. Read the inbox 
-4. look at JIRAPROJECT 
+1. Catch up on slack
+2. Check the calendar
+3. Read the inbox
+4. look at JIRAPROJECT
 
 Notes:
 
@@ -78,10 +78,10 @@ TODO:
 		w := bufio.NewWriter(&b)
 		today.Write(w)
 		expect := `Morning Start Up:
-1. Catch up on slack 
-2. Check the calendar 
-3. Read the inbox 
-4. look at JIRAPROJECT 
+1. Catch up on slack
+2. Check the calendar
+3. Read the inbox
+4. look at JIRAPROJECT
 
 Notes:
 foop boop doop This is a note.
@@ -99,7 +99,7 @@ TODO:
 SOMEJIRA-123 - description of a todo task [IN PROGRESS - waiting for customer - Jun  7, 2020]
 	* Some note
 	* Some other note
-Some other random task 
+Some other random task
 
 `
 		assert.Equal(t, expect, b.String())